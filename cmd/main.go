@@ -19,14 +19,15 @@ import (
 	"os"
 
 	"knative.dev/kn-plugin-quickstart/internal/root"
+	qerrors "knative.dev/kn-plugin-quickstart/pkg/errors"
 )
 
 func main() {
-	err := root.NewRootCommand().Execute()
+	err := root.Execute()
 	if err != nil {
 		if err.Error() != "subcommand is required" {
 			fmt.Fprintln(os.Stderr, err)
 		}
-		os.Exit(1)
+		os.Exit(qerrors.ExitCode(err))
 	}
 }
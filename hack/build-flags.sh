@@ -28,8 +28,13 @@ function build_flags() {
   local branch="`git branch --show-current | cut -d '-' -s -f2`"
   local serving="`git ls-remote --tags --ref https://github.com/knative/serving.git | grep -F "${branch}" | cut -d '-' -f2 | cut -d 'v' -f2 | sort -Vr | head -n 1`"
   local kourier="`git ls-remote --tags --ref https://github.com/knative-extensions/net-kourier.git | grep -F "${branch}" | cut -d '-' -f2 | cut -d 'v' -f2 | sort -Vr | head -n 1`"
+  local contour="`git ls-remote --tags --ref https://github.com/knative-extensions/net-contour.git | grep -F "${branch}" | cut -d '-' -f2 | cut -d 'v' -f2 | sort -Vr | head -n 1`"
+  local istio="`git ls-remote --tags --ref https://github.com/knative-extensions/net-istio.git | grep -F "${branch}" | cut -d '-' -f2 | cut -d 'v' -f2 | sort -Vr | head -n 1`"
+  local gatewayapi="`git ls-remote --tags --ref https://github.com/knative-extensions/net-gateway-api.git | grep -F "${branch}" | cut -d '-' -f2 | cut -d 'v' -f2 | sort -Vr | head -n 1`"
+  local kafka="`git ls-remote --tags --ref https://github.com/knative-extensions/eventing-kafka-broker.git | grep -F "${branch}" | cut -d '-' -f2 | cut -d 'v' -f2 | sort -Vr | head -n 1`"
+  local rabbitmq="`git ls-remote --tags --ref https://github.com/knative-extensions/eventing-rabbitmq.git | grep -F "${branch}" | cut -d '-' -f2 | cut -d 'v' -f2 | sort -Vr | head -n 1`"
   local eventing="`git ls-remote --tags --ref https://github.com/knative/eventing.git | grep -F "${branch}" | cut -d '-' -f2 | cut -d 'v' -f2 | sort -Vr | head -n 1`"
 
 
-  echo "-X '${VERSION_PACKAGE}.BuildDate=${now}' -X ${VERSION_PACKAGE}.Version=${version} -X ${VERSION_PACKAGE}.GitRevision=${rev} -X ${COMPONENT_PACKAGE}.ServingVersion=${serving} -X ${COMPONENT_PACKAGE}.KourierVersion=${kourier} -X ${COMPONENT_PACKAGE}.EventingVersion=${eventing}"
+  echo "-X '${VERSION_PACKAGE}.BuildDate=${now}' -X ${VERSION_PACKAGE}.Version=${version} -X ${VERSION_PACKAGE}.GitRevision=${rev} -X ${COMPONENT_PACKAGE}.ServingVersion=${serving} -X ${COMPONENT_PACKAGE}.KourierVersion=${kourier} -X ${COMPONENT_PACKAGE}.EventingVersion=${eventing} -X ${COMPONENT_PACKAGE}.ContourVersion=${contour} -X ${COMPONENT_PACKAGE}.IstioVersion=${istio} -X ${COMPONENT_PACKAGE}.NetGatewayAPIVersion=${gatewayapi} -X ${COMPONENT_PACKAGE}.EventingKafkaVersion=${kafka} -X ${COMPONENT_PACKAGE}.EventingRabbitMQVersion=${rabbitmq}"
 }
@@ -0,0 +1,75 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"knative.dev/kn-plugin-quickstart/pkg/bundle"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+	"knative.dev/kn-plugin-quickstart/pkg/kind"
+)
+
+// NewBundleCommand implements 'kn quickstart bundle' command
+func NewBundleCommand() *cobra.Command {
+	var bundleCmd = &cobra.Command{
+		Use:   "bundle",
+		Short: "Manage offline bundles",
+		Long: `Manage offline bundles, holding the release manifests and container images
+needed to run 'kn quickstart kind --from-bundle' without network access`,
+	}
+
+	bundleCmd.AddCommand(newBundleCreateCommand())
+
+	return bundleCmd
+}
+
+func newBundleCreateCommand() *cobra.Command {
+	var bundleCreateCmd = &cobra.Command{
+		Use:   "create [FILE]",
+		Short: "Create an offline bundle for the kind command",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "kn-quickstart-bundle.tar.gz"
+			if len(args) == 1 {
+				path = args[0]
+			}
+			if err := install.OverrideVersions(servingVersion, kourierVersion, eventingVersion); err != nil {
+				return err
+			}
+			if err := install.SetManifestSource(manifestSource); err != nil {
+				return err
+			}
+			if err := install.SetIngress(ingress); err != nil {
+				return err
+			}
+			if err := install.SetBrokerClass(brokerClass); err != nil {
+				return err
+			}
+			if err := bundle.Create(cmd.Context(), path, kind.NodeImage(kubernetesVersion)); err != nil {
+				return err
+			}
+			fmt.Println("🎁 Bundle written to " + path)
+			return nil
+		},
+	}
+	kubernetesVersionOption(bundleCreateCmd, "", "kubernetes version of the kind node image to bundle (1.x.y) or (kindest/node:v1.x.y)")
+	componentVersionOptions(bundleCreateCmd)
+	manifestSourceOption(bundleCreateCmd)
+	ingressOption(bundleCreateCmd)
+	brokerClassOption(bundleCreateCmd)
+	return bundleCreateCmd
+}
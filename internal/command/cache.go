@@ -0,0 +1,164 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"knative.dev/kn-plugin-quickstart/pkg/cache"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+)
+
+// NewCacheCommand implements 'kn quickstart cache' command
+func NewCacheCommand() *cobra.Command {
+	var cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the local manifest cache",
+		Long: `Manage the local cache of Knative release manifests. Manifests are downloaded
+once, verified against their recorded SHA-256 digests and reused by later installs.`,
+	}
+
+	cacheCmd.AddCommand(newCacheListCommand())
+	cacheCmd.AddCommand(newCachePruneCommand())
+	cacheCmd.AddCommand(newCachePullCommand())
+
+	return cacheCmd
+}
+
+func newCacheListCommand() *cobra.Command {
+	var cacheListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List cached manifests",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "" && output != "json" {
+				return fmt.Errorf("unsupported output format %q, only \"json\" is supported", output)
+			}
+			c, err := manifestCache()
+			if err != nil {
+				return err
+			}
+			entries, err := c.List()
+			if err != nil {
+				return err
+			}
+			if output == "json" {
+				if entries == nil {
+					entries = []cache.Entry{}
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(entries)
+			}
+			if len(entries) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No cached manifests in "+c.Dir())
+				return nil
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "HOST\tREPOSITORY\tVERSION\tFILE\tSIZE\tSHA256\tVERIFIED")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%.12s\t%t\n", e.Host, e.Repo, e.Version, e.File, e.Size, e.Digest, e.Verified)
+			}
+			return w.Flush()
+		},
+	}
+	outputOption(cacheListCmd)
+	return cacheListCmd
+}
+
+func newCachePruneCommand() *cobra.Command {
+	var all bool
+	var cachePruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Remove cached manifests of versions this plugin does not install",
+		Long: `Remove cached manifests of versions this plugin does not install. The manifests of
+every networking layer and broker class are kept, not only those of the defaults.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := manifestCache()
+			if err != nil {
+				return err
+			}
+			current := map[string]bool{}
+			for _, m := range install.AllManifests() {
+				current[m.Repo+"@"+m.Version] = true
+			}
+			removed, err := c.Prune(func(repo, version string) bool {
+				return !all && current[repo+"@"+version]
+			})
+			if err != nil {
+				return err
+			}
+			for _, e := range removed {
+				fmt.Fprintf(cmd.OutOrStdout(), "    Removed %s/%s %s %s\n", e.Host, e.Repo, e.Version, e.File)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "🧹 Pruned %d cached manifests\n", len(removed))
+			return nil
+		},
+	}
+	cachePruneCmd.Flags().BoolVar(&all, "all", false, "remove all cached manifests")
+	return cachePruneCmd
+}
+
+func newCachePullCommand() *cobra.Command {
+	var cachePullCmd = &cobra.Command{
+		Use:   "pull",
+		Short: "Download the manifests of the selected versions into the cache",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := manifestCache(); err != nil {
+				return err
+			}
+			if err := install.OverrideVersions(servingVersion, kourierVersion, eventingVersion); err != nil {
+				return err
+			}
+			if err := install.SetManifestSource(manifestSource); err != nil {
+				return err
+			}
+			if err := install.SetIngress(ingress); err != nil {
+				return err
+			}
+			if err := install.SetBrokerClass(brokerClass); err != nil {
+				return err
+			}
+			if !install.RemoteManifestSource() {
+				return errors.New("manifests from local directories are not cached")
+			}
+			for _, m := range install.Manifests() {
+				path, err := m.Fetch(cmd.Context())
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "    Cached %s %s %s\n", m.Repo, m.Version, path)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "📦 Manifests cached")
+			return nil
+		},
+	}
+	componentVersionOptions(cachePullCmd)
+	manifestSourceOption(cachePullCmd)
+	ingressOption(cachePullCmd)
+	brokerClassOption(cachePullCmd)
+	return cachePullCmd
+}
+
+// manifestCache returns the cache used by installs
+func manifestCache() (*cache.Cache, error) {
+	if install.ManifestCache == nil {
+		return nil, errors.New("no user cache directory found to cache manifests in")
+	}
+	return install.ManifestCache, nil
+}
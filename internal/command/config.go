@@ -0,0 +1,103 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/spf13/cobra"
+	"knative.dev/kn-plugin-quickstart/pkg/config"
+)
+
+// NewConfigCommand implements 'kn quickstart config' command
+func NewConfigCommand() *cobra.Command {
+	var configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Manage quickstart configuration files",
+		Long: `Manage quickstart configuration files, holding the flag values of the kind,
+minikube, k3d and install commands. Pass them to these commands with --config.`,
+	}
+
+	configCmd.AddCommand(newConfigInitCommand())
+
+	return configCmd
+}
+
+func newConfigInitCommand() *cobra.Command {
+	var force bool
+	var configInitCmd = &cobra.Command{
+		Use:   "init [FILE]",
+		Short: "Write a commented default configuration file",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "quickstart.yaml"
+			if len(args) == 1 {
+				path = args[0]
+			}
+			flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+			if force {
+				flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			}
+			f, err := os.OpenFile(path, flags, 0o644)
+			if errors.Is(err, fs.ErrExist) {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write config file: %w", err)
+			}
+			if _, err := f.WriteString(config.Template); err != nil {
+				f.Close()
+				return fmt.Errorf("failed to write config file: %w", err)
+			}
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("failed to write config file: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "📝 Config written to "+path)
+			return nil
+		},
+	}
+	configInitCmd.Flags().BoolVar(&force, "force", false, "overwrite an existing file")
+	return configInitCmd
+}
+
+// applyConfigFile sets the flags of cmd that were not passed on the command line to
+// the values of the --config file, if any. An empty provider accepts files for all
+// providers.
+func applyConfigFile(cmd *cobra.Command, provider string) error {
+	if configFile == "" {
+		return nil
+	}
+	c, err := config.Load(configFile)
+	if err != nil {
+		return err
+	}
+	if c.Provider != "" && provider != "" && c.Provider != provider {
+		return fmt.Errorf("config file %s is for provider %s, not %s", configFile, c.Provider, provider)
+	}
+	for name, value := range c.Flags() {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return fmt.Errorf("invalid %s in config file %s: %w", name, configFile, err)
+		}
+	}
+	configMaps = c.ConfigMaps
+	return nil
+}
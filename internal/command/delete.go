@@ -0,0 +1,79 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"knative.dev/kn-plugin-quickstart/pkg/k3d"
+	"knative.dev/kn-plugin-quickstart/pkg/kind"
+	"knative.dev/kn-plugin-quickstart/pkg/minikube"
+	"knative.dev/kn-plugin-quickstart/pkg/quickstart"
+)
+
+// NewDeleteCommand implements 'kn quickstart delete' command
+func NewDeleteCommand() *cobra.Command {
+	var deleteCmd = &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a quickstart cluster",
+		Long:  `Delete a quickstart cluster together with its local registry and kubeconfig context`,
+	}
+
+	deleteCmd.AddCommand(newDeleteKindCommand())
+	deleteCmd.AddCommand(newDeleteMinikubeCommand())
+	deleteCmd.AddCommand(newDeleteK3dCommand())
+
+	return deleteCmd
+}
+
+func newDeleteKindCommand() *cobra.Command {
+	var deleteKindCmd = &cobra.Command{
+		Use:   "kind",
+		Short: "Delete a Kind quickstart cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Println("Deleting Knative Quickstart using Kind")
+			return quickstart.Delete(cmd.Context(), kind.NewProvider(kind.Options{Name: name}, nil))
+		},
+	}
+	clusterNameOption(deleteKindCmd, "knative")
+	return deleteKindCmd
+}
+
+func newDeleteMinikubeCommand() *cobra.Command {
+	var deleteMinikubeCmd = &cobra.Command{
+		Use:   "minikube",
+		Short: "Delete a Minikube quickstart cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Println("Deleting Knative Quickstart using Minikube")
+			return quickstart.Delete(cmd.Context(), minikube.NewProvider(minikube.Options{Name: name}, nil))
+		},
+	}
+	clusterNameOption(deleteMinikubeCmd, "knative")
+	return deleteMinikubeCmd
+}
+
+func newDeleteK3dCommand() *cobra.Command {
+	var deleteK3dCmd = &cobra.Command{
+		Use:   "k3d",
+		Short: "Delete a k3d quickstart cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Println("Deleting Knative Quickstart using k3d")
+			return quickstart.Delete(cmd.Context(), k3d.NewProvider(k3d.Options{Name: name}, nil))
+		},
+	}
+	clusterNameOption(deleteK3dCmd, "knative")
+	return deleteK3dCmd
+}
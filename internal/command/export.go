@@ -0,0 +1,86 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"knative.dev/kn-plugin-quickstart/pkg/existing"
+	"knative.dev/kn-plugin-quickstart/pkg/export"
+	"knative.dev/kn-plugin-quickstart/pkg/prompt"
+	"knative.dev/kn-plugin-quickstart/pkg/quickstart"
+)
+
+// NewExportCommand implements 'kn quickstart export' command
+func NewExportCommand() *cobra.Command {
+	var outputDir string
+	var exportIngressType string
+	var nodeAddress string
+
+	var exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export the installation as a kustomize directory",
+		Long: `Export the release manifests and resources quickstart installs, and its ConfigMap
+patches, as a kustomize directory for GitOps tools like Argo CD. The export is
+rendered from the flags, not read from a cluster: pass the same flags or --config
+file as for the install to reproduce it.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyConfigFile(cmd, ""); err != nil {
+				return err
+			}
+			if outputDir == "" {
+				return errors.New("--output is required")
+			}
+			if entries, err := os.ReadDir(outputDir); err == nil && len(entries) > 0 {
+				return fmt.Errorf("output directory %s is not empty", outputDir)
+			}
+			var loadBalancer bool
+			switch exportIngressType {
+			case existing.IngressNodePort:
+			case existing.IngressLoadBalancer:
+				loadBalancer = true
+			default:
+				return fmt.Errorf("unsupported ingress type %q, must be one of %s or %s", exportIngressType, existing.IngressNodePort, existing.IngressLoadBalancer)
+			}
+
+			exporter := export.New(outputDir)
+			opts := installOptions(prompt.New(promptOptions))
+			opts.ExistingCluster = true
+			opts.DryRun = exporter
+			if err := quickstart.Run(cmd.Context(), export.NewProvider(nodeAddress, loadBalancer), opts); err != nil {
+				return err
+			}
+			if err := exporter.Close(); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "📁 Exported to "+outputDir+", apply it with: kubectl apply -k "+outputDir)
+			return nil
+		},
+	}
+	exportCmd.Flags().StringVar(&outputDir, "output", "", "directory to write the kustomize layout to")
+	exportCmd.Flags().StringVar(&exportIngressType, "ingress-type", existing.IngressNodePort, "how to expose the networking layer, one of: nodeport, loadbalancer")
+	exportCmd.Flags().StringVar(&nodeAddress, "node-address", "127.0.0.1", "address the NodePort is reachable on, used for the sslip.io domain")
+	installServingOption(exportCmd)
+	installEventingOption(exportCmd)
+	componentVersionOptions(exportCmd)
+	manifestSourceOption(exportCmd)
+	ingressOption(exportCmd)
+	brokerClassOption(exportCmd)
+	configOption(exportCmd)
+	return exportCmd
+}
@@ -15,9 +15,18 @@
 package command
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"knative.dev/kn-plugin-quickstart/pkg/config"
+	"knative.dev/kn-plugin-quickstart/pkg/dryrun"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+	"knative.dev/kn-plugin-quickstart/pkg/prompt"
+	"knative.dev/kn-plugin-quickstart/pkg/quickstart"
 )
 
 var name string
@@ -25,9 +34,34 @@ var kubernetesVersion string
 var installServing bool
 var installEventing bool
 var installKindRegistry bool
+var installK3dRegistry bool
 var installKindExtraMountHostPath string
 var installKindExtraMountContainerPath string
 var kindHostPort int
+var output string
+var kubeconfig string
+var kubeContext string
+var ingressType string
+var servingVersion string
+var kourierVersion string
+var eventingVersion string
+var manifestSource string
+var fromBundle string
+var ingress string
+var brokerClass string
+var configFile string
+var configMaps []config.ConfigMapPatch
+var dryRun bool
+var dryRunDir string
+var showPlan bool
+var skipSteps []string
+var onlySteps []string
+var resume bool
+var cleanupOnFailure bool
+var timeout time.Duration
+var stepTimeouts map[string]string
+var useKubectl bool
+var promptOptions prompt.Options
 
 func clusterNameOption(targetCmd *cobra.Command, flagDefault string) {
 	targetCmd.Flags().StringVarP(
@@ -56,10 +90,73 @@ func installEventingOption(targetCmd *cobra.Command) {
 	targetCmd.Flags().BoolVar(&installEventing, "install-eventing", false, "install Eventing on quickstart cluster")
 }
 
+func componentVersionOptions(targetCmd *cobra.Command) {
+	targetCmd.Flags().StringVar(&servingVersion, "serving-version", "", "Knative Serving version to install (1.x.y, default is the version this plugin was built for)")
+	targetCmd.Flags().StringVar(&kourierVersion, "kourier-version", "", "Kourier version to install (1.x.y, default is the version this plugin was built for)")
+	targetCmd.Flags().StringVar(&eventingVersion, "eventing-version", "", "Knative Eventing version to install (1.x.y, default is the version this plugin was built for)")
+}
+
+func manifestSourceOption(targetCmd *cobra.Command) {
+	targetCmd.Flags().StringVar(&manifestSource, "manifest-source", "", "local directory, file:// path or HTTP mirror URL to read release manifests from, with the same layout as GitHub release assets (default https://github.com)")
+}
+
+func ingressOption(targetCmd *cobra.Command) {
+	targetCmd.Flags().StringVar(&ingress, "ingress", "kourier", "networking layer to install with Serving, one of: "+strings.Join(install.IngressNames(), ", "))
+}
+
+func brokerClassOption(targetCmd *cobra.Command) {
+	targetCmd.Flags().StringVar(&brokerClass, "broker-class", "mt-channel", "broker class to install with Eventing, one of: "+strings.Join(install.BrokerClassNames(), ", "))
+}
+
+func configOption(targetCmd *cobra.Command) {
+	targetCmd.Flags().StringVar(&configFile, "config", "", "quickstart configuration file, as written by 'kn quickstart config init'; flags override its values")
+}
+
+func dryRunOptions(targetCmd *cobra.Command) {
+	targetCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the cluster configuration and every manifest and resource in the order they would be applied, without changing anything")
+	targetCmd.Flags().StringVar(&dryRunDir, "dry-run-dir", "", "write the dry run output to this directory, one numbered file per change (implies --dry-run)")
+}
+
+func stepOptions(targetCmd *cobra.Command) {
+	targetCmd.Flags().BoolVar(&showPlan, "plan", false, "print the installation steps in order and exit")
+	targetCmd.Flags().StringSliceVar(&skipSteps, "skip-step", nil, "skip the named installation steps, one of: "+strings.Join(quickstart.StepNames, ", "))
+	targetCmd.Flags().StringSliceVar(&onlySteps, "only-step", nil, "run only the named installation steps, see --skip-step")
+	targetCmd.MarkFlagsMutuallyExclusive("skip-step", "only-step")
+	targetCmd.Flags().BoolVar(&resume, "resume", false, "continue the last installation on the cluster, which failed, from the first step that did not complete or is not healthy anymore")
+	targetCmd.MarkFlagsMutuallyExclusive("resume", "dry-run")
+	targetCmd.MarkFlagsMutuallyExclusive("resume", "dry-run-dir")
+}
+
+func cleanupOnFailureOption(targetCmd *cobra.Command) {
+	targetCmd.Flags().BoolVar(&cleanupOnFailure, "cleanup-on-failure", false, "delete the cluster and registry created by quickstart when the installation fails or is interrupted")
+}
+
+func timeoutOptions(targetCmd *cobra.Command) {
+	targetCmd.Flags().DurationVar(&timeout, "timeout", 0, "limit the whole installation, e.g. 30m; waits and retries end with it instead of after their own default timeouts (default no limit)")
+	targetCmd.Flags().StringToStringVar(&stepTimeouts, "step-timeout", nil, "limit single installation steps, e.g. serving-core=15m,cluster=5m, see --skip-step for the step names")
+}
+
+func useKubectlOption(targetCmd *cobra.Command) {
+	targetCmd.Flags().BoolVar(&useKubectl, "use-kubectl", false, "apply, patch and wait for resources with kubectl instead of the Kubernetes API")
+}
+
+func fromBundleOption(targetCmd *cobra.Command) {
+	targetCmd.Flags().StringVar(&fromBundle, "from-bundle", "", "install without network access from a bundle written by 'kn quickstart bundle create'")
+	targetCmd.MarkFlagsMutuallyExclusive("from-bundle", "kubernetes-version")
+	targetCmd.MarkFlagsMutuallyExclusive("from-bundle", "serving-version")
+	targetCmd.MarkFlagsMutuallyExclusive("from-bundle", "kourier-version")
+	targetCmd.MarkFlagsMutuallyExclusive("from-bundle", "eventing-version")
+	targetCmd.MarkFlagsMutuallyExclusive("from-bundle", "manifest-source")
+}
+
 func installKindRegistryOption(targetCmd *cobra.Command) {
 	targetCmd.Flags().BoolVar(&installKindRegistry, "registry", false, "install registry for Kind quickstart cluster")
 }
 
+func installK3dRegistryOption(targetCmd *cobra.Command) {
+	targetCmd.Flags().BoolVar(&installK3dRegistry, "registry", false, "create a k3d-managed registry for k3d quickstart cluster")
+}
+
 func installKindExtraMountHostPathOption(targetCmd *cobra.Command) {
 	targetCmd.Flags().StringVarP(&installKindExtraMountHostPath, "extraMountHostPath", "", "", "set the extraMount hostPath on Kind quickstart cluster")
 }
@@ -71,3 +168,84 @@ func installKindExtraMountContainerPathOption(targetCmd *cobra.Command) {
 func kindHostPortOption(targetCmd *cobra.Command) {
 	targetCmd.Flags().IntVar(&kindHostPort, "host-port", 80, "host port to expose Kourier ingress on (use a non-privileged port >=1024 for rootless container runtimes like Podman)")
 }
+
+func outputOption(targetCmd *cobra.Command) {
+	targetCmd.Flags().StringVarP(&output, "output", "o", "", "output format, one of: json")
+}
+
+func kubeconfigOptions(targetCmd *cobra.Command) {
+	targetCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "kubeconfig file of the cluster to install on (default is kubectl's default)")
+	targetCmd.Flags().StringVar(&kubeContext, "context", "", "kubeconfig context of the cluster to install on (default is the current context)")
+}
+
+func ingressTypeOption(targetCmd *cobra.Command) {
+	targetCmd.Flags().StringVar(&ingressType, "ingress-type", "auto", "how to expose the networking layer, one of: auto, loadbalancer, nodeport")
+}
+
+func nonInteractiveOptions(targetCmd *cobra.Command) {
+	targetCmd.Flags().BoolVarP(&promptOptions.Yes, "yes", "y", false, "answer yes to all questions and do not wait for input")
+	targetCmd.Flags().BoolVar(&promptOptions.Recreate, "recreate", false, "delete and recreate an existing quickstart cluster without asking")
+	targetCmd.Flags().BoolVar(&promptOptions.ReuseExisting, "reuse-existing", false, "keep an existing quickstart cluster without asking")
+	targetCmd.Flags().BoolVar(&promptOptions.IgnoreVersionWarnings, "ignore-version-warnings", false, "continue with outdated kind, minikube or k3d versions without asking")
+	targetCmd.MarkFlagsMutuallyExclusive("recreate", "reuse-existing")
+}
+
+// installOptions returns the quickstart options selected by the install flags
+func installOptions(prompter prompt.Prompter) quickstart.Options {
+	opts := quickstart.Options{
+		InstallServing:   installServing,
+		InstallEventing:  installEventing,
+		Prompter:         prompter,
+		ServingVersion:   servingVersion,
+		KourierVersion:   kourierVersion,
+		EventingVersion:  eventingVersion,
+		ManifestSource:   manifestSource,
+		Ingress:          ingress,
+		BrokerClass:      brokerClass,
+		ConfigMaps:       configMaps,
+		SkipSteps:        skipSteps,
+		OnlySteps:        onlySteps,
+		Resume:           resume,
+		CleanupOnFailure: cleanupOnFailure,
+		Timeout:          timeout,
+		UseKubectl:       useKubectl,
+	}
+	if dryRun || dryRunDir != "" {
+		opts.DryRun = dryrun.NewPrinter(os.Stdout, dryRunDir)
+	}
+	return opts
+}
+
+// runQuickstart runs the installation, or prints its steps with --plan
+func runQuickstart(ctx context.Context, p quickstart.ClusterProvider, opts quickstart.Options) error {
+	var err error
+	if opts.StepTimeouts, err = parseStepTimeouts(stepTimeouts); err != nil {
+		return err
+	}
+	if showPlan {
+		return quickstart.PrintPlan(os.Stdout, p, opts)
+	}
+	if err := quickstart.Run(ctx, p, opts); err != nil {
+		return err
+	}
+	if opts.DryRun != nil {
+		fmt.Println("🔍 Dry run finished, nothing was changed")
+	}
+	return nil
+}
+
+// parseStepTimeouts parses the durations of --step-timeout
+func parseStepTimeouts(timeouts map[string]string) (map[string]time.Duration, error) {
+	if len(timeouts) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string]time.Duration, len(timeouts))
+	for step, value := range timeouts {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid --step-timeout %s=%s, must be a positive duration like 10m", step, value)
+		}
+		parsed[step] = d
+	}
+	return parsed, nil
+}
@@ -0,0 +1,66 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	"knative.dev/kn-plugin-quickstart/pkg/existing"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+	"knative.dev/kn-plugin-quickstart/pkg/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+// NewInstallCommand implements 'kn quickstart install' command
+func NewInstallCommand() *cobra.Command {
+
+	var installCmd = &cobra.Command{
+		Use:   "install",
+		Short: "Install Knative on an existing cluster",
+		Long: `Install Knative on an existing cluster, selected by kubeconfig and context.
+The cluster is neither created nor deleted by quickstart.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyConfigFile(cmd, "existing"); err != nil {
+				return err
+			}
+			fmt.Println("Running Knative Quickstart on an existing cluster")
+			install.Kubeconfig = kubeconfig
+			provider := existing.NewProvider(existing.Options{
+				Kubeconfig:  kubeconfig,
+				Context:     kubeContext,
+				IngressType: ingressType,
+			})
+			opts := installOptions(prompt.New(promptOptions))
+			opts.ExistingCluster = true
+			return runQuickstart(cmd.Context(), provider, opts)
+		},
+	}
+	// Set installCmd options
+	kubeconfigOptions(installCmd)
+	ingressTypeOption(installCmd)
+	installServingOption(installCmd)
+	installEventingOption(installCmd)
+	componentVersionOptions(installCmd)
+	manifestSourceOption(installCmd)
+	ingressOption(installCmd)
+	brokerClassOption(installCmd)
+	configOption(installCmd)
+	dryRunOptions(installCmd)
+	stepOptions(installCmd)
+	timeoutOptions(installCmd)
+	useKubectlOption(installCmd)
+	return installCmd
+}
@@ -0,0 +1,65 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"knative.dev/kn-plugin-quickstart/pkg/k3d"
+	"knative.dev/kn-plugin-quickstart/pkg/prompt"
+)
+
+// NewK3dCommand implements 'kn quickstart k3d' command
+func NewK3dCommand() *cobra.Command {
+	var k3dCmd = &cobra.Command{
+		Use:   "k3d",
+		Short: "Quickstart with k3d",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyConfigFile(cmd, "k3d"); err != nil {
+				return err
+			}
+			fmt.Println("Running Knative Quickstart using k3d")
+			prompter := prompt.New(promptOptions)
+			provider := k3d.NewProvider(k3d.Options{
+				Name:              name,
+				KubernetesVersion: kubernetesVersion,
+				Registry:          installK3dRegistry,
+				HostPort:          kindHostPort,
+			}, prompter)
+			return runQuickstart(cmd.Context(), provider, installOptions(prompter))
+		},
+	}
+	// Set k3dCmd options
+	clusterNameOption(k3dCmd, "knative")
+	kubernetesVersionOption(k3dCmd, "", "kubernetes version to use (1.x.y) or (rancher/k3s:v1.x.y-k3s1)")
+	installServingOption(k3dCmd)
+	installEventingOption(k3dCmd)
+	componentVersionOptions(k3dCmd)
+	manifestSourceOption(k3dCmd)
+	ingressOption(k3dCmd)
+	brokerClassOption(k3dCmd)
+	configOption(k3dCmd)
+	dryRunOptions(k3dCmd)
+	stepOptions(k3dCmd)
+	timeoutOptions(k3dCmd)
+	useKubectlOption(k3dCmd)
+	cleanupOnFailureOption(k3dCmd)
+	installK3dRegistryOption(k3dCmd)
+	kindHostPortOption(k3dCmd)
+	nonInteractiveOptions(k3dCmd)
+
+	return k3dCmd
+}
@@ -18,7 +18,10 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"knative.dev/kn-plugin-quickstart/pkg/bundle"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
 	"knative.dev/kn-plugin-quickstart/pkg/kind"
+	"knative.dev/kn-plugin-quickstart/pkg/prompt"
 )
 
 // NewKindCommand implements 'kn quickstart kind' command
@@ -27,8 +30,53 @@ func NewKindCommand() *cobra.Command {
 		Use:   "kind",
 		Short: "Quickstart with Kind",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyConfigFile(cmd, "kind"); err != nil {
+				return err
+			}
 			fmt.Println("Running Knative Quickstart using Kind")
-			return kind.SetUp(name, kubernetesVersion, installServing, installEventing, installKindRegistry, installKindExtraMountHostPath, installKindExtraMountContainerPath, kindHostPort)
+			prompter := prompt.New(promptOptions)
+			kindOpts := kind.Options{
+				Name:                    name,
+				KubernetesVersion:       kubernetesVersion,
+				Registry:                installKindRegistry,
+				ExtraMountHostPath:      installKindExtraMountHostPath,
+				ExtraMountContainerPath: installKindExtraMountContainerPath,
+				HostPort:                kindHostPort,
+			}
+			opts := installOptions(prompter)
+			if fromBundle != "" {
+				fmt.Println("📦 Opening bundle " + fromBundle + "...")
+				b, err := bundle.Open(fromBundle)
+				if err != nil {
+					return err
+				}
+				defer b.Close()
+				kindOpts.KubernetesVersion = b.NodeImage
+				kindOpts.NodeImageArchive = b.NodeImageArchive()
+				kindOpts.ImageArchive = b.ImageArchive()
+				opts.ServingVersion = b.ServingVersion
+				opts.KourierVersion = b.KourierVersion
+				opts.EventingVersion = b.EventingVersion
+				opts.ManifestSource = b.ManifestSource()
+				opts.Ingress = b.Ingress
+				opts.BrokerClass = b.BrokerClass
+				if b.ContourVersion != "" {
+					install.ContourVersion = b.ContourVersion
+				}
+				if b.IstioVersion != "" {
+					install.IstioVersion = b.IstioVersion
+				}
+				if b.NetGatewayAPIVersion != "" {
+					install.NetGatewayAPIVersion = b.NetGatewayAPIVersion
+				}
+				if b.EventingKafkaVersion != "" {
+					install.EventingKafkaVersion = b.EventingKafkaVersion
+				}
+				if b.EventingRabbitMQVersion != "" {
+					install.EventingRabbitMQVersion = b.EventingRabbitMQVersion
+				}
+			}
+			return runQuickstart(cmd.Context(), kind.NewProvider(kindOpts, prompter), opts)
 		},
 	}
 	// Set kindCmd options
@@ -36,10 +84,24 @@ func NewKindCommand() *cobra.Command {
 	kubernetesVersionOption(kindCmd, "", "kubernetes version to use (1.x.y) or (kindest/node:v1.x.y)")
 	installServingOption(kindCmd)
 	installEventingOption(kindCmd)
+	componentVersionOptions(kindCmd)
+	manifestSourceOption(kindCmd)
+	ingressOption(kindCmd)
+	brokerClassOption(kindCmd)
+	configOption(kindCmd)
+	dryRunOptions(kindCmd)
+	stepOptions(kindCmd)
+	timeoutOptions(kindCmd)
+	useKubectlOption(kindCmd)
+	cleanupOnFailureOption(kindCmd)
 	installKindRegistryOption(kindCmd)
 	installKindExtraMountHostPathOption(kindCmd)
 	installKindExtraMountContainerPathOption(kindCmd)
 	kindHostPortOption(kindCmd)
+	nonInteractiveOptions(kindCmd)
+	fromBundleOption(kindCmd)
+	kindCmd.MarkFlagsMutuallyExclusive("from-bundle", "ingress")
+	kindCmd.MarkFlagsMutuallyExclusive("from-bundle", "broker-class")
 
 	return kindCmd
 }
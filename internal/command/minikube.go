@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"knative.dev/kn-plugin-quickstart/pkg/minikube"
+	"knative.dev/kn-plugin-quickstart/pkg/prompt"
 
 	"github.com/spf13/cobra"
 )
@@ -29,8 +30,17 @@ func NewMinikubeCommand() *cobra.Command {
 		Use:   "minikube",
 		Short: "Quickstart with Minikube",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyConfigFile(cmd, "minikube"); err != nil {
+				return err
+			}
 			fmt.Println("Running Knative Quickstart using Minikube")
-			return minikube.SetUp(name, kubernetesVersion, installServing, installEventing, args)
+			prompter := prompt.New(promptOptions)
+			provider := minikube.NewProvider(minikube.Options{
+				Name:              name,
+				KubernetesVersion: kubernetesVersion,
+				Args:              args,
+			}, prompter)
+			return runQuickstart(cmd.Context(), provider, installOptions(prompter))
 		},
 	}
 	// Set minikubeCmd options
@@ -38,5 +48,16 @@ func NewMinikubeCommand() *cobra.Command {
 	kubernetesVersionOption(minikubeCmd, "", "kubernetes version to use (1.x.y)")
 	installServingOption(minikubeCmd)
 	installEventingOption(minikubeCmd)
+	componentVersionOptions(minikubeCmd)
+	manifestSourceOption(minikubeCmd)
+	ingressOption(minikubeCmd)
+	brokerClassOption(minikubeCmd)
+	configOption(minikubeCmd)
+	dryRunOptions(minikubeCmd)
+	stepOptions(minikubeCmd)
+	timeoutOptions(minikubeCmd)
+	useKubectlOption(minikubeCmd)
+	cleanupOnFailureOption(minikubeCmd)
+	nonInteractiveOptions(minikubeCmd)
 	return minikubeCmd
 }
@@ -0,0 +1,50 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"knative.dev/kn-plugin-quickstart/pkg/status"
+)
+
+// NewStatusCommand implements 'kn quickstart status' command
+func NewStatusCommand() *cobra.Command {
+	// The cluster name filter has no default, so it does not share the name flag
+	// variable of the other commands
+	var clusterName string
+	var statusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Report the health of quickstart clusters",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "" && output != "json" {
+				return fmt.Errorf("unsupported output format %q, only \"json\" is supported", output)
+			}
+			clusters, err := status.Detect(cmd.Context(), clusterName)
+			if err != nil {
+				return err
+			}
+			if output == "json" {
+				return status.PrintJSON(cmd.OutOrStdout(), clusters)
+			}
+			status.Print(cmd.OutOrStdout(), clusters)
+			return nil
+		},
+	}
+	statusCmd.Flags().StringVarP(&clusterName, "name", "n", "", "only report the quickstart cluster with this name")
+	outputOption(statusCmd)
+	return statusCmd
+}
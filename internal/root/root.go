@@ -15,6 +15,11 @@
 package root
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/spf13/cobra"
 	"knative.dev/kn-plugin-quickstart/internal/command"
 )
@@ -29,7 +34,28 @@ func NewRootCommand() *cobra.Command {
 
 	rootCmd.AddCommand(command.NewKindCommand())
 	rootCmd.AddCommand(command.NewMinikubeCommand())
+	rootCmd.AddCommand(command.NewK3dCommand())
+	rootCmd.AddCommand(command.NewInstallCommand())
+	rootCmd.AddCommand(command.NewDeleteCommand())
+	rootCmd.AddCommand(command.NewStatusCommand())
+	rootCmd.AddCommand(command.NewCacheCommand())
+	rootCmd.AddCommand(command.NewBundleCommand())
+	rootCmd.AddCommand(command.NewConfigCommand())
+	rootCmd.AddCommand(command.NewExportCommand())
 	rootCmd.AddCommand(command.NewVersionCommand())
 
 	return rootCmd
 }
+
+// Execute runs the plugin's command line. The context of the commands is cancelled on
+// the first interrupt, so that the running step stops cleanly. A second interrupt
+// terminates the plugin right away.
+func Execute() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return NewRootCommand().ExecuteContext(ctx)
+}
@@ -0,0 +1,390 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bundle creates and opens offline bundles, tarballs holding the release
+// manifests and every container image needed to install Knative on a kind cluster
+// without network access.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/image"
+	dclient "github.com/docker/docker/client"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+	"knative.dev/kn-plugin-quickstart/pkg/kind"
+)
+
+// Files of a bundle
+const (
+	metadataFile      = "bundle.json"
+	manifestsDir      = "manifests"
+	nodeImagesArchive = "node-images.tar"
+	imagesArchive     = "images.tar"
+)
+
+var (
+	digestImageRegexp = regexp.MustCompile(`[a-z0-9][a-z0-9._\-/:]*@sha256:[a-f0-9]{64}`)
+	tagImageRegexp    = regexp.MustCompile(`(?m)^\s*-?\s*image:\s*["']?([^\s"'@]+)["']?\s*$`)
+)
+
+// Metadata describes the content of a bundle
+type Metadata struct {
+	ServingVersion  string `json:"servingVersion"`
+	KourierVersion  string `json:"kourierVersion"`
+	EventingVersion string `json:"eventingVersion"`
+	Ingress         string `json:"ingress"`
+	ContourVersion  string `json:"contourVersion,omitempty"`
+	IstioVersion    string `json:"istioVersion,omitempty"`
+	// NetGatewayAPIVersion is set for the gateway-api ingress. The Gateway API and
+	// Envoy Gateway versions are pinned in the plugin.
+	NetGatewayAPIVersion string `json:"netGatewayAPIVersion,omitempty"`
+	BrokerClass          string `json:"brokerClass,omitempty"`
+	EventingKafkaVersion string `json:"eventingKafkaVersion,omitempty"`
+	// EventingRabbitMQVersion is set for the rabbitmq broker class. The versions of
+	// cert-manager and the RabbitMQ operators are pinned in the plugin.
+	EventingRabbitMQVersion string   `json:"eventingRabbitMQVersion,omitempty"`
+	NodeImage               string   `json:"nodeImage"`
+	Images                  []string `json:"images"`
+}
+
+// Create writes a bundle to path, holding the release manifests of the current
+// component versions, the kind node image, the local registry image and every image
+// referenced by the manifests
+func Create(ctx context.Context, path, nodeImage string) error {
+	dcli, err := kind.CheckDocker(ctx)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "kn-quickstart-bundle-")
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tags, err := collectManifests(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("🐳 Pulling images...")
+	nodeImages := []string{nodeImage, kind.RegistryImage}
+	for _, ref := range nodeImages {
+		if err := pullImage(ctx, dcli, ref); err != nil {
+			return err
+		}
+	}
+	images := make([]string, 0, len(tags))
+	for ref, tag := range tags {
+		if err := pullImage(ctx, dcli, ref); err != nil {
+			return err
+		}
+		if tag != ref {
+			if err := dcli.ImageTag(ctx, ref, tag); err != nil {
+				return fmt.Errorf("failed to tag image %s: %w", ref, err)
+			}
+		}
+		images = append(images, tag)
+	}
+	sort.Strings(images)
+
+	fmt.Println("💾 Saving images...")
+	if err := saveImages(ctx, dcli, nodeImages, filepath.Join(dir, nodeImagesArchive)); err != nil {
+		return err
+	}
+	if err := saveImages(ctx, dcli, images, filepath.Join(dir, imagesArchive)); err != nil {
+		return err
+	}
+
+	bundled := Metadata{
+		ServingVersion:  install.ServingVersion,
+		KourierVersion:  install.KourierVersion,
+		EventingVersion: install.EventingVersion,
+		Ingress:         install.Ingress.Name(),
+		BrokerClass:     install.Broker.Name(),
+		NodeImage:       nodeImage,
+		Images:          images,
+	}
+	switch bundled.Ingress {
+	case "contour":
+		bundled.ContourVersion = install.ContourVersion
+	case "istio":
+		bundled.IstioVersion = install.IstioVersion
+	case "gateway-api":
+		bundled.NetGatewayAPIVersion = install.NetGatewayAPIVersion
+	}
+	switch bundled.BrokerClass {
+	case "kafka":
+		bundled.EventingKafkaVersion = install.EventingKafkaVersion
+	case "rabbitmq":
+		bundled.EventingRabbitMQVersion = install.EventingRabbitMQVersion
+	}
+	metadata, err := json.MarshalIndent(bundled, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, metadataFile), metadata, 0o644); err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+
+	fmt.Println("📦 Writing " + path + "...")
+	return writeArchive(dir, path)
+}
+
+// collectManifests writes the release manifests of the current component versions to
+// dir, and returns the images to bundle by reference, with the tags they are saved as
+func collectManifests(ctx context.Context, dir string) (map[string]string, error) {
+	fmt.Println("📄 Collecting manifests...")
+	tags := map[string]string{}
+	for _, m := range install.Manifests() {
+		content, err := m.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// Images are saved without their registry digest, so digest references are
+		// replaced by tags that survive docker save and load
+		for _, ref := range findImages(content) {
+			tags[ref] = retag(ref)
+			content = strings.ReplaceAll(content, ref, tags[ref])
+		}
+		target := filepath.Join(dir, manifestsDir, filepath.FromSlash(m.Path()))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create bundle: %w", err)
+		}
+		if err := os.WriteFile(target, []byte(content), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to create bundle: %w", err)
+		}
+		fmt.Println("    " + m.Path())
+	}
+
+	// The networking layer and broker class run images their manifests do not reference
+	for _, ref := range slices.Concat(install.Ingress.Images(), install.Broker.Images()) {
+		tags[ref] = ref
+	}
+	return tags, nil
+}
+
+// Bundle is a bundle extracted to a temporary directory
+type Bundle struct {
+	Metadata
+	dir string
+}
+
+// Open extracts the bundle at path. Close removes the extracted files.
+func Open(path string) (*Bundle, error) {
+	dir, err := os.MkdirTemp("", "kn-quickstart-bundle-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	b := &Bundle{dir: dir}
+	if err := extractArchive(path, dir); err != nil {
+		b.Close()
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, metadataFile))
+	if err != nil {
+		b.Close()
+		return nil, fmt.Errorf("failed to open bundle %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &b.Metadata); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("failed to parse bundle %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// ManifestSource returns the directory holding the manifests of the bundle, to be used
+// as install.ManifestSource
+func (b *Bundle) ManifestSource() string {
+	return filepath.Join(b.dir, manifestsDir)
+}
+
+// NodeImageArchive returns the archive holding the node and local registry images
+func (b *Bundle) NodeImageArchive() string {
+	return filepath.Join(b.dir, nodeImagesArchive)
+}
+
+// ImageArchive returns the archive holding the images referenced by the manifests
+func (b *Bundle) ImageArchive() string {
+	return filepath.Join(b.dir, imagesArchive)
+}
+
+// Close removes the extracted bundle
+func (b *Bundle) Close() error {
+	return os.RemoveAll(b.dir)
+}
+
+// findImages returns the images referenced by a manifest, by digest or by tag
+func findImages(manifest string) []string {
+	set := map[string]bool{}
+	for _, ref := range digestImageRegexp.FindAllString(manifest, -1) {
+		set[ref] = true
+	}
+	for _, m := range tagImageRegexp.FindAllStringSubmatch(manifest, -1) {
+		set[m[1]] = true
+	}
+	refs := make([]string, 0, len(set))
+	for ref := range set {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// retag returns the tag a digest reference is saved as, e.g. repo:sha256-<digest> for
+// repo@sha256:<digest>. Tag references are returned unchanged.
+func retag(ref string) string {
+	name, digest, ok := strings.Cut(ref, "@sha256:")
+	if !ok {
+		return ref
+	}
+	// Drop a tag given together with the digest
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return name + ":sha256-" + digest
+}
+
+func pullImage(ctx context.Context, dcli *dclient.Client, ref string) error {
+	fmt.Println("    " + ref)
+	rc, err := dcli.ImagePull(ctx, ref, image.PullOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", ref, err)
+	}
+	defer rc.Close()
+	if _, err := io.Copy(io.Discard, rc); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", ref, err)
+	}
+	return nil
+}
+
+func saveImages(ctx context.Context, dcli *dclient.Client, refs []string, path string) error {
+	rc, err := dcli.ImageSave(ctx, refs)
+	if err != nil {
+		return fmt.Errorf("failed to save images: %w", err)
+	}
+	defer rc.Close()
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to save images: %w", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, rc); err != nil {
+		return fmt.Errorf("failed to save images: %w", err)
+	}
+	return f.Close()
+}
+
+// writeArchive writes the files of dir to a gzipped tarball at path
+func writeArchive(dir, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	err = filepath.Walk(dir, func(file string, fi os.FileInfo, err error) error {
+		if err != nil || !fi.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		src, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return f.Close()
+}
+
+// extractArchive extracts the gzipped tarball at path into dir
+func extractArchive(path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to open bundle %s: %w", path, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read bundle %s: %w", path, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid file %q in bundle %s", hdr.Name, path)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("failed to extract bundle: %w", err)
+		}
+		dst, err := os.Create(target)
+		if err != nil {
+			return fmt.Errorf("failed to extract bundle: %w", err)
+		}
+		if _, err := io.Copy(dst, tr); err != nil {
+			dst.Close()
+			return fmt.Errorf("failed to extract bundle: %w", err)
+		}
+		if err := dst.Close(); err != nil {
+			return fmt.Errorf("failed to extract bundle: %w", err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+)
+
+const digest = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestFindImages(t *testing.T) {
+	manifest := `
+spec:
+  containers:
+    - name: controller
+      image: gcr.io/knative-releases/knative.dev/serving/cmd/controller@sha256:` + digest + `
+    - image: docker.io/envoyproxy/envoy:v1.31-latest
+data:
+  queue-sidecar-image: gcr.io/knative-releases/knative.dev/serving/cmd/queue@sha256:` + digest + `
+`
+	assert.DeepEqual(t, findImages(manifest), []string{
+		"docker.io/envoyproxy/envoy:v1.31-latest",
+		"gcr.io/knative-releases/knative.dev/serving/cmd/controller@sha256:" + digest,
+		"gcr.io/knative-releases/knative.dev/serving/cmd/queue@sha256:" + digest,
+	})
+}
+
+func TestRetag(t *testing.T) {
+	assert.Equal(t, retag("gcr.io/knative-releases/queue@sha256:"+digest), "gcr.io/knative-releases/queue:sha256-"+digest)
+	assert.Equal(t, retag("localhost:5000/queue:v1@sha256:"+digest), "localhost:5000/queue:sha256-"+digest)
+	assert.Equal(t, retag("docker.io/envoyproxy/envoy:v1.31-latest"), "docker.io/envoyproxy/envoy:v1.31-latest")
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	manifest := filepath.Join(src, manifestsDir, "knative/serving/releases/download/knative-v1.17.0/serving-core.yaml")
+	assert.NilError(t, os.MkdirAll(filepath.Dir(manifest), 0o755))
+	assert.NilError(t, os.WriteFile(manifest, []byte("kind: Namespace\n"), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(src, metadataFile), []byte(`{"servingVersion":"1.17.0","nodeImage":"kindest/node:v1.34.0"}`), 0o644))
+
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	assert.NilError(t, writeArchive(src, path))
+
+	b, err := Open(path)
+	assert.NilError(t, err)
+	defer b.Close()
+	assert.Equal(t, b.ServingVersion, "1.17.0")
+	assert.Equal(t, b.NodeImage, "kindest/node:v1.34.0")
+	data, err := os.ReadFile(filepath.Join(b.ManifestSource(), "knative/serving/releases/download/knative-v1.17.0/serving-core.yaml"))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(data), "Namespace"))
+}
+
+func TestCollectManifestsGatewayAPI(t *testing.T) {
+	t.Cleanup(func() {
+		install.ManifestSource = install.DefaultManifestSource
+		assert.NilError(t, install.SetIngress("kourier"))
+	})
+	assert.NilError(t, install.SetIngress("gateway-api"))
+	src := t.TempDir()
+	for _, m := range install.Manifests() {
+		path := filepath.Join(src, filepath.FromSlash(m.Path()))
+		assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NilError(t, os.WriteFile(path, []byte("image: gcr.io/knative-releases/controller@sha256:"+digest+"\n"), 0o644))
+	}
+	assert.NilError(t, install.SetManifestSource(src))
+
+	tags, err := collectManifests(context.Background(), t.TempDir())
+	assert.NilError(t, err)
+	assert.Equal(t, tags[install.EnvoyProxyImage], install.EnvoyProxyImage)
+	assert.Equal(t, tags["gcr.io/knative-releases/controller@sha256:"+digest], "gcr.io/knative-releases/controller:sha256-"+digest)
+}
@@ -0,0 +1,309 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache stores downloaded release manifests, so that they are fetched once and
+// verified against their recorded SHA-256 digests on reuse.
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sumsFile records the digests of the files of a version directory, in the format
+// written by sha256sum
+const sumsFile = "sha256sums"
+
+var httpClient = &http.Client{Timeout: 2 * time.Minute}
+
+// ErrDigestMismatch is returned when a downloaded file does not match the digest
+// recorded when it was cached, i.e. the source serves different content for the version
+var ErrDigestMismatch = errors.New("does not match its recorded digest")
+
+// Cache is a manifest cache laid out as <dir>/<host>/<repo>/<version>/<file>, e.g.
+// <dir>/github.com/knative/serving/1.17.0/serving-core.yaml. The host of the source is
+// part of the key, so a mirror and github.com do not share entries.
+type Cache struct {
+	dir string
+}
+
+// New returns a Cache storing manifests in dir
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Default returns the Cache under the user cache directory, or nil if there is no
+// user cache directory
+func Default() *Cache {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	return New(filepath.Join(dir, "kn-quickstart", "manifests"))
+}
+
+// Dir returns the directory of the cache
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Entry is a cached manifest
+type Entry struct {
+	Host    string `json:"host"`
+	Repo    string `json:"repo"`
+	Version string `json:"version"`
+	File    string `json:"file"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Digest  string `json:"digest"`
+	// Verified is false when the file does not match its recorded digest
+	Verified bool `json:"verified"`
+}
+
+// Fetch returns the path of the cached file of the given repository and version. The
+// file is downloaded from location if it is not cached yet, or if it does not match its
+// recorded digest anymore. A file downloaded again must match the recorded digest, or
+// ErrDigestMismatch is returned. The download is canceled once ctx is done.
+func (c *Cache) Fetch(ctx context.Context, location, repo, version, file string) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", location, err)
+	}
+	dir := filepath.Join(c.dir, hostDir(u.Host), filepath.FromSlash(repo), version)
+	path := filepath.Join(dir, file)
+
+	sums, err := readSums(dir)
+	if err != nil {
+		return "", err
+	}
+	want, cached := sums[file]
+	if cached {
+		got, err := digest(path)
+		if err == nil && got == want {
+			return path, nil
+		}
+		fmt.Printf("    Cached %s %s does not match its recorded digest, downloading it again\n", repo, file)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	sum, err := download(ctx, location, path)
+	if err != nil {
+		return "", err
+	}
+	if cached && sum != want {
+		return "", fmt.Errorf("downloaded %s %w (want %.12s, got %.12s), run 'kn quickstart cache prune --all' to clear the cache",
+			location, ErrDigestMismatch, want, sum)
+	}
+	sums[file] = sum
+	if err := writeSums(dir, sums); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// List returns all cached manifests, verifying them against their recorded digests
+func (c *Cache) List() ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == c.dir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != sumsFile {
+			return nil
+		}
+		versionDir := filepath.Dir(path)
+		rel, err := filepath.Rel(c.dir, filepath.Dir(versionDir))
+		if err != nil {
+			return err
+		}
+		host, repo, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		sums, err := readSums(versionDir)
+		if err != nil {
+			return err
+		}
+		for file, want := range sums {
+			e := Entry{
+				Host:    host,
+				Repo:    repo,
+				Version: filepath.Base(versionDir),
+				File:    file,
+				Path:    filepath.Join(versionDir, file),
+				Digest:  want,
+			}
+			if fi, err := os.Stat(e.Path); err == nil {
+				e.Size = fi.Size()
+			}
+			got, err := digest(e.Path)
+			e.Verified = err == nil && got == want
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Host != entries[j].Host {
+			return entries[i].Host < entries[j].Host
+		}
+		if entries[i].Repo != entries[j].Repo {
+			return entries[i].Repo < entries[j].Repo
+		}
+		if entries[i].Version != entries[j].Version {
+			return entries[i].Version < entries[j].Version
+		}
+		return entries[i].File < entries[j].File
+	})
+	return entries, nil
+}
+
+// Prune removes the cached versions for which keep returns false, and returns the
+// removed entries
+func (c *Cache) Prune(keep func(repo, version string) bool) ([]Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	var removed []Entry
+	dirs := map[string]bool{}
+	for _, e := range entries {
+		if keep(e.Repo, e.Version) {
+			continue
+		}
+		removed = append(removed, e)
+		dirs[filepath.Dir(e.Path)] = true
+	}
+	for dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, fmt.Errorf("failed to prune cache: %w", err)
+		}
+	}
+	return removed, nil
+}
+
+// hostDir returns the directory name of the cache entries downloaded from host. Ports are
+// kept apart with an underscore, as colons are not allowed in Windows paths.
+func hostDir(host string) string {
+	if host == "" {
+		return "_"
+	}
+	return strings.ReplaceAll(host, ":", "_")
+}
+
+// download writes the body of url to path and returns its SHA-256 digest
+func download(ctx context.Context, url, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), resp.Body); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to store %s: %w", url, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// digest returns the SHA-256 digest of the file at path
+func digest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readSums returns the recorded digests of the files in dir, by file name
+func readSums(dir string) (map[string]string, error) {
+	sums := map[string]string{}
+	f, err := os.Open(filepath.Join(dir, sumsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return sums, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache digests: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if sum, file, ok := strings.Cut(scanner.Text(), "  "); ok {
+			sums[file] = sum
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cache digests: %w", err)
+	}
+	return sums, nil
+}
+
+// writeSums records the digests of the files in dir
+func writeSums(dir string, sums map[string]string) error {
+	files := make([]string, 0, len(sums))
+	for file := range sums {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	var b strings.Builder
+	for _, file := range files {
+		fmt.Fprintf(&b, "%s  %s\n", sums[file], file)
+	}
+	if err := os.WriteFile(filepath.Join(dir, sumsFile), []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write cache digests: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestFetchVerifiesAndReuses(t *testing.T) {
+	downloads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		fmt.Fprint(w, "kind: ConfigMap\n")
+	}))
+	defer srv.Close()
+
+	c := New(t.TempDir())
+	path, err := c.Fetch(context.Background(), srv.URL+"/serving-core.yaml", "knative/serving", "1.17.0", "serving-core.yaml")
+	assert.NilError(t, err)
+	_, err = c.Fetch(context.Background(), srv.URL+"/serving-core.yaml", "knative/serving", "1.17.0", "serving-core.yaml")
+	assert.NilError(t, err)
+	assert.Equal(t, downloads, 1)
+
+	entries, err := c.List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Host, hostDir(strings.TrimPrefix(srv.URL, "http://")))
+	assert.Equal(t, entries[0].Repo, "knative/serving")
+	assert.Equal(t, entries[0].Version, "1.17.0")
+	assert.Assert(t, entries[0].Verified)
+
+	// A modified file is downloaded again
+	assert.NilError(t, os.WriteFile(path, []byte("tampered"), 0o644))
+	entries, err = c.List()
+	assert.NilError(t, err)
+	assert.Assert(t, !entries[0].Verified)
+	_, err = c.Fetch(context.Background(), srv.URL+"/serving-core.yaml", "knative/serving", "1.17.0", "serving-core.yaml")
+	assert.NilError(t, err)
+	assert.Equal(t, downloads, 2)
+}
+
+func TestFetchFailsOnChangedContent(t *testing.T) {
+	content := "kind: ConfigMap\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, content)
+	}))
+	defer srv.Close()
+
+	c := New(t.TempDir())
+	path, err := c.Fetch(context.Background(), srv.URL+"/serving-core.yaml", "knative/serving", "1.17.0", "serving-core.yaml")
+	assert.NilError(t, err)
+
+	// The source serves different content for the same version
+	content = "kind: Secret\n"
+	assert.NilError(t, os.WriteFile(path, []byte("tampered"), 0o644))
+	_, err = c.Fetch(context.Background(), srv.URL+"/serving-core.yaml", "knative/serving", "1.17.0", "serving-core.yaml")
+	assert.ErrorIs(t, err, ErrDigestMismatch)
+	assert.ErrorContains(t, err, "kn quickstart cache prune --all")
+
+	// The recorded digest is kept
+	entries, err := c.List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 1)
+	assert.Assert(t, !entries[0].Verified)
+}
+
+func TestFetchKeysBySourceHost(t *testing.T) {
+	handler := func(content string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, content)
+		})
+	}
+	github := httptest.NewServer(handler("kind: ConfigMap\n"))
+	defer github.Close()
+	mirror := httptest.NewServer(handler("kind: Secret\n"))
+	defer mirror.Close()
+
+	c := New(t.TempDir())
+	for _, srv := range []*httptest.Server{github, mirror} {
+		_, err := c.Fetch(context.Background(), srv.URL+"/serving-core.yaml", "knative/serving", "1.17.0", "serving-core.yaml")
+		assert.NilError(t, err)
+	}
+
+	entries, err := c.List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 2)
+	assert.Assert(t, entries[0].Host != entries[1].Host)
+	assert.Assert(t, entries[0].Verified && entries[1].Verified)
+}
+
+func TestFetchFailsOnHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, err := New(t.TempDir()).Fetch(context.Background(), srv.URL+"/kourier.yaml", "knative-extensions/net-kourier", "1.17.0", "kourier.yaml")
+	assert.ErrorContains(t, err, "404 Not Found")
+}
+
+func TestFetchCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "kind: ConfigMap\n")
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := New(t.TempDir()).Fetch(ctx, srv.URL+"/serving-core.yaml", "knative/serving", "1.17.0", "serving-core.yaml")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestPrune(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	c := New(t.TempDir())
+	for _, v := range []string{"1.16.0", "1.17.0"} {
+		_, err := c.Fetch(context.Background(), srv.URL+"/"+v, "knative/eventing", v, "eventing-core.yaml")
+		assert.NilError(t, err)
+	}
+
+	removed, err := c.Prune(func(repo, version string) bool { return version == "1.17.0" })
+	assert.NilError(t, err)
+	assert.Equal(t, len(removed), 1)
+	assert.Equal(t, removed[0].Version, "1.16.0")
+
+	entries, err := c.List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Version, "1.17.0")
+}
+
+func TestListEmptyCache(t *testing.T) {
+	entries, err := New(t.TempDir() + "/missing").List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 0)
+}
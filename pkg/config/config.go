@@ -0,0 +1,219 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config reads quickstart configuration files, which hold the values of the
+// quickstart flags so that a setup can be shared within a team.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// APIVersion and Kind identify the schema of the configuration file
+const (
+	APIVersion = "quickstart.knative.dev/v1alpha1"
+	Kind       = "Config"
+)
+
+// Config is a quickstart configuration file
+type Config struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	// Provider is the provider the file is meant for: kind, minikube, k3d or
+	// existing. An empty provider matches all of them.
+	Provider       string           `yaml:"provider"`
+	Cluster        Cluster          `yaml:"cluster"`
+	Components     Components       `yaml:"components"`
+	ManifestSource string           `yaml:"manifestSource"`
+	ConfigMaps     []ConfigMapPatch `yaml:"configMaps"`
+}
+
+// Cluster configures the quickstart cluster
+type Cluster struct {
+	Name              string     `yaml:"name"`
+	KubernetesVersion string     `yaml:"kubernetesVersion"`
+	Registry          bool       `yaml:"registry"`
+	HostPort          int        `yaml:"hostPort"`
+	ExtraMount        ExtraMount `yaml:"extraMount"`
+}
+
+// ExtraMount is a host directory mounted into the kind node
+type ExtraMount struct {
+	HostPath      string `yaml:"hostPath"`
+	ContainerPath string `yaml:"containerPath"`
+}
+
+// Components selects the installed Knative components and their versions
+type Components struct {
+	Serving  Serving  `yaml:"serving"`
+	Eventing Eventing `yaml:"eventing"`
+	Ingress  Ingress  `yaml:"ingress"`
+}
+
+// Serving configures Knative Serving
+type Serving struct {
+	Enabled bool   `yaml:"enabled"`
+	Version string `yaml:"version"`
+}
+
+// Eventing configures Knative Eventing
+type Eventing struct {
+	Enabled     bool   `yaml:"enabled"`
+	Version     string `yaml:"version"`
+	BrokerClass string `yaml:"brokerClass"`
+}
+
+// Ingress configures the networking layer of Knative Serving
+type Ingress struct {
+	Name           string `yaml:"name"`
+	KourierVersion string `yaml:"kourierVersion"`
+}
+
+// ConfigMapPatch is data merged into a ConfigMap once the components are installed
+type ConfigMapPatch struct {
+	Namespace string            `yaml:"namespace"`
+	Name      string            `yaml:"name"`
+	Data      map[string]string `yaml:"data"`
+}
+
+// Load reads and validates the configuration file at path
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Parse decodes and validates a configuration file. Unknown fields are rejected.
+func Parse(data []byte) (*Config, error) {
+	c := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if c.APIVersion != APIVersion || c.Kind != Kind {
+		return nil, fmt.Errorf("unsupported schema %s %s, must be %s %s", c.APIVersion, c.Kind, APIVersion, Kind)
+	}
+	switch c.Provider {
+	case "", "kind", "minikube", "k3d", "existing":
+	default:
+		return nil, fmt.Errorf("unsupported provider %q, must be one of: kind, minikube, k3d, existing", c.Provider)
+	}
+	for _, cm := range c.ConfigMaps {
+		if cm.Namespace == "" || cm.Name == "" {
+			return nil, errors.New("configMaps entries need a namespace and a name")
+		}
+	}
+	return c, nil
+}
+
+// Flags returns the values of the file by the name of the flag they set. Unset values
+// are left out, so that they keep the flag defaults.
+func (c *Config) Flags() map[string]string {
+	flags := map[string]string{}
+	set := func(name, value string) {
+		if value != "" {
+			flags[name] = value
+		}
+	}
+	set("name", c.Cluster.Name)
+	set("kubernetes-version", c.Cluster.KubernetesVersion)
+	if c.Cluster.Registry {
+		set("registry", "true")
+	}
+	if c.Cluster.HostPort != 0 {
+		set("host-port", strconv.Itoa(c.Cluster.HostPort))
+	}
+	set("extraMountHostPath", c.Cluster.ExtraMount.HostPath)
+	set("extraMountContainerPath", c.Cluster.ExtraMount.ContainerPath)
+	if c.Components.Serving.Enabled {
+		set("install-serving", "true")
+	}
+	set("serving-version", c.Components.Serving.Version)
+	if c.Components.Eventing.Enabled {
+		set("install-eventing", "true")
+	}
+	set("eventing-version", c.Components.Eventing.Version)
+	set("broker-class", c.Components.Eventing.BrokerClass)
+	set("ingress", c.Components.Ingress.Name)
+	set("kourier-version", c.Components.Ingress.KourierVersion)
+	set("manifest-source", c.ManifestSource)
+	return flags
+}
+
+// Template is the commented default configuration file written by 'config init'
+const Template = `# kn quickstart configuration file, used with --config. Flags passed on the command
+# line override the values of this file.
+apiVersion: ` + APIVersion + `
+kind: ` + Kind + `
+
+# Provider the file is meant for: kind, minikube, k3d or existing. Leave it empty to
+# use the file with any of them.
+provider: kind
+
+cluster:
+  # Name of the quickstart cluster
+  name: knative
+  # Kubernetes version (1.x.y), or kind node image, of the cluster. Empty selects the
+  # version this plugin was built for.
+  kubernetesVersion: ""
+  # Create a local container registry (kind and k3d)
+  registry: false
+  # Host port the ingress is exposed on (kind)
+  hostPort: 80
+  # Host directory mounted into the cluster node (kind)
+  extraMount:
+    hostPath: ""
+    containerPath: ""
+
+# Components to install. When neither Serving nor Eventing is enabled, both are
+# installed. Empty versions select the versions this plugin was built for.
+components:
+  serving:
+    enabled: true
+    version: ""
+  eventing:
+    enabled: true
+    version: ""
+    # One of: kafka, mt-channel, rabbitmq
+    brokerClass: mt-channel
+  ingress:
+    # One of: contour, gateway-api, istio, kourier
+    name: kourier
+    kourierVersion: ""
+
+# Local directory, file:// path or HTTP mirror URL to read release manifests from.
+# Empty reads them from https://github.com.
+manifestSource: ""
+
+# Data merged into ConfigMaps once the components are installed, e.g.
+#  - namespace: knative-serving
+#    name: config-features
+#    data:
+#      kubernetes.podspec-fieldref: enabled
+configMaps: []
+`
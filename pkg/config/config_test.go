@@ -0,0 +1,69 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestTemplate(t *testing.T) {
+	c, err := Parse([]byte(Template))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, c.Flags(), map[string]string{
+		"name":             "knative",
+		"host-port":        "80",
+		"install-serving":  "true",
+		"install-eventing": "true",
+		"broker-class":     "mt-channel",
+		"ingress":          "kourier",
+	})
+}
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]byte(`apiVersion: quickstart.knative.dev/v1alpha1
+kind: Config
+cluster:
+  registry: true
+components:
+  serving:
+    enabled: true
+    version: 1.17.0
+configMaps:
+  - namespace: knative-serving
+    name: config-features
+    data:
+      kubernetes.podspec-fieldref: enabled
+`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, c.Flags(), map[string]string{
+		"registry":        "true",
+		"install-serving": "true",
+		"serving-version": "1.17.0",
+	})
+	assert.Equal(t, c.ConfigMaps[0].Data["kubernetes.podspec-fieldref"], "enabled")
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse([]byte("apiVersion: quickstart.knative.dev/v2\nkind: Config\n"))
+	assert.ErrorContains(t, err, "unsupported schema")
+
+	_, err = Parse([]byte("apiVersion: quickstart.knative.dev/v1alpha1\nkind: Config\nprovider: docker\n"))
+	assert.ErrorContains(t, err, `unsupported provider "docker"`)
+
+	_, err = Parse([]byte("apiVersion: quickstart.knative.dev/v1alpha1\nkind: Config\ncluster:\n  port: 80\n"))
+	assert.ErrorContains(t, err, "field port not found")
+}
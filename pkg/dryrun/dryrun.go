@@ -0,0 +1,117 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dryrun shows the changes quickstart would make, without making them.
+package dryrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+)
+
+// Printer is an install.Recorder writing every change as a YAML document, numbered
+// in the order the changes are made. Changes that are not resources are written as
+// YAML comments.
+type Printer struct {
+	out io.Writer
+	dir string
+	n   int
+}
+
+var _ install.Recorder = (*Printer)(nil)
+
+// NewPrinter returns a Printer writing to out, or to one file per change in dir when
+// dir is set
+func NewPrinter(out io.Writer, dir string) *Printer {
+	return &Printer{out: out, dir: dir}
+}
+
+// Manifest records the location of the release manifest
+func (p *Printer) Manifest(ctx context.Context, m install.Manifest) error {
+	return p.record(m.File, "# kubectl apply -f "+m.Location()+"\n")
+}
+
+// Resources records the resources as they are applied
+func (p *Printer) Resources(title, config string) error {
+	return p.record(title, config)
+}
+
+// ConfigMapPatch records the patch as a partial ConfigMap
+func (p *Printer) ConfigMapPatch(ns, name string, data map[string]string) error {
+	content, err := ConfigMapPatch(ns, name, data)
+	if err != nil {
+		return err
+	}
+	return p.record(ns+"-"+name, fmt.Sprintf("# kubectl patch configmap %s -n %s --type merge\n%s", name, ns, content))
+}
+
+// Step records a change made outside of the cluster
+func (p *Printer) Step(title, content string) error {
+	return p.record(title, content)
+}
+
+func (p *Printer) record(title, content string) error {
+	p.n++
+	content = strings.TrimLeft(content, "\n")
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if p.dir == "" {
+		_, err := fmt.Fprintf(p.out, "--- # %d. %s\n%s", p.n, title, content)
+		return err
+	}
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create dry run directory: %w", err)
+	}
+	path := filepath.Join(p.dir, fmt.Sprintf("%02d-%s.yaml", p.n, fileName(title)))
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write dry run output: %w", err)
+	}
+	fmt.Fprintln(p.out, "    Wrote "+path)
+	return nil
+}
+
+var unsafeChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// fileName turns a title into a file name, e.g. "kind cluster" to "kind-cluster"
+func fileName(title string) string {
+	name := strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(title), ".yaml"), ".yml")
+	return strings.Trim(unsafeChars.ReplaceAllString(name, "-"), "-")
+}
+
+// ConfigMapPatch returns the ConfigMap holding only the given data, which merges into
+// the existing ConfigMap as a patch
+func ConfigMapPatch(ns, name string, data map[string]string) (string, error) {
+	cm := struct {
+		APIVersion string            `yaml:"apiVersion"`
+		Kind       string            `yaml:"kind"`
+		Metadata   map[string]string `yaml:"metadata"`
+		Data       map[string]string `yaml:"data"`
+	}{"v1", "ConfigMap", map[string]string{"name": name, "namespace": ns}, data}
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(cm); err != nil {
+		return "", fmt.Errorf("configmap %s/%s patch: %w", ns, name, err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,58 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dryrun
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+)
+
+func TestPrinter(t *testing.T) {
+	var out bytes.Buffer
+	p := NewPrinter(&out, "")
+	assert.NilError(t, p.Manifest(context.Background(), install.Manifest{Repo: "knative/serving", Version: "1.17.0", File: "serving-core.yaml"}))
+	assert.NilError(t, p.ConfigMapPatch("knative-serving", "config-network", map[string]string{"ingress.class": "kourier.ingress.networking.knative.dev"}))
+	assert.Equal(t, out.String(), `--- # 1. serving-core.yaml
+# kubectl apply -f https://github.com/knative/serving/releases/download/knative-v1.17.0/serving-core.yaml
+--- # 2. knative-serving-config-network
+# kubectl patch configmap config-network -n knative-serving --type merge
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-network
+  namespace: knative-serving
+data:
+  ingress.class: kourier.ingress.networking.knative.dev
+`)
+}
+
+func TestPrinterDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "plan")
+	p := NewPrinter(&bytes.Buffer{}, dir)
+	assert.NilError(t, p.Step("kind cluster", "\nkind: Cluster"))
+	assert.NilError(t, p.Resources("example-broker", "kind: Broker\n"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "01-kind-cluster.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), "kind: Cluster\n")
+	_, err = os.Stat(filepath.Join(dir, "02-example-broker.yaml"))
+	assert.NilError(t, err)
+}
@@ -0,0 +1,111 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package errors contains the typed errors returned by quickstart, and the process
+// exit codes they map to.
+package errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes returned by the kn-quickstart binary. They are documented in the README.
+const (
+	ExitOK           = 0
+	ExitError        = 1
+	ExitPrerequisite = 3
+	ExitVersion      = 4
+	ExitAborted      = 5
+	ExitInstall      = 6
+	ExitInterrupted  = 130
+)
+
+// ErrAborted is returned when the user declined to continue, or when an answer was
+// needed but could not be asked for
+var ErrAborted = errors.New("installation aborted")
+
+// ErrInterrupted is returned when the installation was interrupted, e.g. with Ctrl-C
+var ErrInterrupted = errors.New("installation interrupted")
+
+// PrerequisiteError is returned when a tool required by quickstart is missing or not running
+type PrerequisiteError struct {
+	Tool string
+	Hint string
+	Err  error
+}
+
+func (e *PrerequisiteError) Error() string {
+	msg := e.Tool + " is required for quickstart"
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Hint != "" {
+		msg += "\n" + e.Hint
+	}
+	return msg
+}
+
+func (e *PrerequisiteError) Unwrap() error {
+	return e.Err
+}
+
+// VersionError is returned when a tool is older than recommended and the user chose
+// not to continue
+type VersionError struct {
+	Tool    string
+	Version float64
+	Minimum float64
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("%s v%.2f is older than the recommended v%.2f, please upgrade %s and run again", e.Tool, e.Version, e.Minimum, e.Tool)
+}
+
+// InstallError is returned when a step of the installation failed
+type InstallError struct {
+	Step string
+	Err  error
+}
+
+func (e *InstallError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InstallError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit code for the given error
+func ExitCode(err error) int {
+	var prerequisiteErr *PrerequisiteError
+	var versionErr *VersionError
+	var installErr *InstallError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &prerequisiteErr):
+		return ExitPrerequisite
+	case errors.As(err, &versionErr):
+		return ExitVersion
+	case errors.Is(err, ErrAborted):
+		return ExitAborted
+	case errors.Is(err, ErrInterrupted):
+		return ExitInterrupted
+	case errors.As(err, &installErr):
+		return ExitInstall
+	default:
+		return ExitError
+	}
+}
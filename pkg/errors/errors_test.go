@@ -0,0 +1,49 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"generic", errors.New("boom"), ExitError},
+		{"prerequisite", fmt.Errorf("setup: %w", &PrerequisiteError{Tool: "kubectl"}), ExitPrerequisite},
+		{"version inside install step", &InstallError{Step: "cluster", Err: &VersionError{Tool: "kind", Version: 0.2, Minimum: 0.3}}, ExitVersion},
+		{"aborted", fmt.Errorf("%w: input required", ErrAborted), ExitAborted},
+		{"install", &InstallError{Step: "serving", Err: errors.New("timeout")}, ExitInstall},
+		{"interrupted", &InstallError{Step: "serving-core", Err: fmt.Errorf("%w during step serving-core", ErrInterrupted)}, ExitInterrupted},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, ExitCode(tc.err), tc.want)
+		})
+	}
+}
+
+func TestVersionErrorMessage(t *testing.T) {
+	err := &VersionError{Tool: "kind", Version: 0.2, Minimum: 0.3}
+	assert.Equal(t, err.Error(), "kind v0.20 is older than the recommended v0.30, please upgrade kind and run again")
+}
@@ -0,0 +1,246 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package existing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+	"knative.dev/kn-plugin-quickstart/pkg/quickstart"
+)
+
+// Ingress types accepted by Options.IngressType
+const (
+	IngressAuto         = "auto"
+	IngressLoadBalancer = "loadbalancer"
+	IngressNodePort     = "nodeport"
+)
+
+// Options selects the existing cluster Knative is installed on
+type Options struct {
+	// Kubeconfig is the kubeconfig file to use, or "" for kubectl's default
+	Kubeconfig string
+	// Context is the kubeconfig context to use, or "" for the current context
+	Context string
+	// IngressType is how the networking layer is exposed, one of auto, loadbalancer or nodeport
+	IngressType string
+}
+
+// Provider is the quickstart.ClusterProvider installing onto a cluster quickstart does
+// not manage. It never creates or deletes the cluster.
+type Provider struct {
+	opts Options
+}
+
+var _ quickstart.ClusterProvider = (*Provider)(nil)
+
+// NewProvider returns a Provider for the cluster selected by opts. Without a context, it
+// resolves the current context of the kubeconfig, so that the installation and its
+// state are pinned to the cluster even when preflight does not run.
+func NewProvider(opts Options) *Provider {
+	if opts.IngressType == "" {
+		opts.IngressType = IngressAuto
+	}
+	if opts.Context == "" {
+		opts.Context = currentContext(opts.Kubeconfig)
+	}
+	return &Provider{opts: opts}
+}
+
+// currentContext returns the current context of the kubeconfig, which defaults to
+// kubectl's, or "" if it cannot be loaded or has none, which Preflight reports
+func currentContext(kubeconfig string) string {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return ""
+	}
+	return config.CurrentContext
+}
+
+// Name returns the provider name
+func (e *Provider) Name() string {
+	return "existing"
+}
+
+// ClusterName returns the kubeconfig context of the cluster
+func (e *Provider) ClusterName() string {
+	return e.opts.Context
+}
+
+// KubeContext returns the kubeconfig context of the cluster
+func (e *Provider) KubeContext() string {
+	return e.opts.Context
+}
+
+// Preflight checks that a kubeconfig context is selected and the cluster is reachable
+func (e *Provider) Preflight(ctx context.Context) error {
+	switch e.opts.IngressType {
+	case IngressAuto, IngressLoadBalancer, IngressNodePort:
+	default:
+		return fmt.Errorf("unsupported ingress type %q, must be one of %s, %s or %s",
+			e.opts.IngressType, IngressAuto, IngressLoadBalancer, IngressNodePort)
+	}
+
+	if e.opts.Context == "" {
+		return errors.New("unable to get the current kubeconfig context, select the cluster with --context")
+	}
+
+	fmt.Println("✅ Checking cluster " + e.opts.Context + "...")
+	if out, err := e.kubectl(ctx, "get", "namespaces", "--request-timeout=10s").CombinedOutput(); err != nil {
+		fmt.Println(string(out))
+		return fmt.Errorf("unable to reach cluster %s: %w", e.opts.Context, err)
+	}
+	return nil
+}
+
+// Exists always reports true, the cluster is expected to be there
+func (e *Provider) Exists(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+// Create is not supported, quickstart does not create existing clusters
+func (e *Provider) Create(ctx context.Context) error {
+	return errors.New("quickstart does not create clusters it does not manage")
+}
+
+// Delete is not supported, quickstart does not delete existing clusters
+func (e *Provider) Delete(ctx context.Context) error {
+	return errors.New("quickstart does not delete clusters it does not manage")
+}
+
+// ConfigureRegistry does nothing, existing clusters bring their own registries
+func (e *Provider) ConfigureRegistry(ctx context.Context) (string, error) {
+	return "", nil
+}
+
+// ConfigureIngress exposes the networking layer through its LoadBalancer service when the cluster
+// assigns it an address, or through a NodePort otherwise
+func (e *Provider) ConfigureIngress(ctx context.Context) error {
+	if install.DryRun != nil {
+		// The cluster is not asked for LoadBalancer support or node addresses
+		if e.opts.IngressType == IngressNodePort {
+			return install.Ingress.ExposeNodePort(ctx, "<node-address>")
+		}
+		return install.Ingress.ExposeLoadBalancer(ctx)
+	}
+	switch e.opts.IngressType {
+	case IngressLoadBalancer:
+		fmt.Println("    Waiting for the LoadBalancer address...")
+		address := install.Ingress.LoadBalancerAddress(ctx, 5*time.Minute)
+		if address == "" {
+			return errors.New("no address was assigned to the LoadBalancer service of the networking layer, use --ingress-type nodeport on clusters without LoadBalancer support")
+		}
+		return loadBalancer(ctx, address)
+	case IngressNodePort:
+		return e.nodePort(ctx)
+	default:
+		fmt.Println("    Detecting LoadBalancer support...")
+		if address := install.Ingress.LoadBalancerAddress(ctx, time.Minute); address != "" {
+			return loadBalancer(ctx, address)
+		}
+		fmt.Println("    No LoadBalancer address assigned, falling back to NodePort")
+		return e.nodePort(ctx)
+	}
+}
+
+// loadBalancer sets up the domain for the address of the networking layer LoadBalancer
+func loadBalancer(ctx context.Context, address string) error {
+	fmt.Println("    LoadBalancer address is " + address)
+	switch {
+	case net.ParseIP(address) != nil:
+		return install.Ingress.ExposeLoadBalancer(ctx)
+	case address == "localhost":
+		// Docker Desktop and Rancher Desktop expose LoadBalancers on the host
+		return install.ConfigureDomain(ctx, "127.0.0.1.sslip.io")
+	default:
+		fmt.Println("WARNING: the LoadBalancer has hostname " + address + ", configure a domain for Knative Services manually:")
+		fmt.Println("https://knative.dev/docs/install/operator/configuring-serving-cr/#configuring-a-custom-domain")
+		return nil
+	}
+}
+
+// nodePort exposes the networking layer on NodePort 31080 of the node reachable from the host
+func (e *Provider) nodePort(ctx context.Context) error {
+	nodeIP, err := e.nodeAddress(ctx)
+	if err != nil {
+		return err
+	}
+	if err := install.Ingress.ExposeNodePort(ctx, nodeIP); err != nil {
+		return err
+	}
+	fmt.Println("    Knative Services are reachable on port 31080 of " + nodeIP)
+	return nil
+}
+
+// nodeAddress returns the address NodePorts are reachable on. Clusters whose API server
+// runs on the host, like Docker Desktop, expose NodePorts on localhost as well.
+func (e *Provider) nodeAddress(ctx context.Context) (string, error) {
+	server, err := e.kubectl(ctx, "config", "view", "--minify", "-o", "jsonpath={.clusters[0].cluster.server}").Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to get the API server of cluster %s: %w", e.opts.Context, err)
+	}
+	if isLocalServer(string(server)) {
+		return "127.0.0.1", nil
+	}
+
+	for _, addressType := range []string{"ExternalIP", "InternalIP"} {
+		out, err := e.kubectl(ctx, "get", "nodes", "-o",
+			`jsonpath={.items[0].status.addresses[?(@.type=="`+addressType+`")].address}`).Output()
+		if err != nil {
+			return "", fmt.Errorf("unable to get node addresses of cluster %s: %w", e.opts.Context, err)
+		}
+		if address := strings.Fields(string(out)); len(address) > 0 {
+			return address[0], nil
+		}
+	}
+	return "", fmt.Errorf("no node address found for cluster %s", e.opts.Context)
+}
+
+// isLocalServer reports whether the API server URL points to the local host
+func isLocalServer(server string) bool {
+	u, err := url.Parse(strings.TrimSpace(server))
+	if err != nil {
+		return false
+	}
+	switch host := u.Hostname(); host {
+	case "localhost", "kubernetes.docker.internal":
+		return true
+	default:
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	}
+}
+
+// kubectl returns a kubectl command targeting the selected cluster
+func (e *Provider) kubectl(ctx context.Context, args ...string) *exec.Cmd {
+	var flags []string
+	if e.opts.Kubeconfig != "" {
+		flags = append(flags, "--kubeconfig", e.opts.Kubeconfig)
+	}
+	if e.opts.Context != "" {
+		flags = append(flags, "--context", e.opts.Context)
+	}
+	return exec.CommandContext(ctx, "kubectl", append(flags, args...)...)
+}
@@ -0,0 +1,70 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package existing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestIsLocalServer(t *testing.T) {
+	for server, want := range map[string]bool{
+		"https://127.0.0.1:6443":                  true,
+		"https://localhost:6443":                  true,
+		"https://kubernetes.docker.internal:6443": true,
+		"https://[::1]:6443":                      true,
+		"https://10.0.0.12:6443":                  false,
+		"https://api.dev.example.com":             false,
+		"":                                        false,
+	} {
+		assert.Equal(t, isLocalServer(server), want, server)
+	}
+}
+
+func TestPreflightRejectsUnknownIngressType(t *testing.T) {
+	p := NewProvider(Options{IngressType: "ingress"})
+	assert.ErrorContains(t, p.Preflight(context.Background()), `unsupported ingress type "ingress"`)
+}
+
+func TestNewProviderResolvesCurrentContext(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	assert.NilError(t, os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: dev
+  context:
+    cluster: dev
+current-context: dev
+`), 0o600))
+
+	p := NewProvider(Options{Kubeconfig: kubeconfig})
+	assert.Equal(t, p.KubeContext(), "dev")
+	assert.Equal(t, p.ClusterName(), "dev")
+
+	p = NewProvider(Options{Kubeconfig: kubeconfig, Context: "prod"})
+	assert.Equal(t, p.KubeContext(), "prod")
+
+	p = NewProvider(Options{Kubeconfig: filepath.Join(t.TempDir(), "missing")})
+	assert.Equal(t, p.KubeContext(), "")
+	assert.ErrorContains(t, p.Preflight(context.Background()), "select the cluster with --context")
+}
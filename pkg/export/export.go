@@ -0,0 +1,283 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package export writes the resources of a quickstart installation as a kustomize
+// directory, to reproduce it with GitOps tools.
+package export
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"knative.dev/kn-plugin-quickstart/pkg/dryrun"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+	"knative.dev/kn-plugin-quickstart/pkg/quickstart"
+)
+
+const (
+	resourcesDir = "resources"
+	patchesDir   = "patches"
+)
+
+// Exporter is an install.Recorder writing the release manifests and inline resources
+// as kustomize resources, and the ConfigMap patches as strategic merge patches.
+// Resources recorded more than once, like the CRDs that serving-core.yaml and
+// eventing-core.yaml repeat, are written once, as they were recorded last.
+type Exporter struct {
+	dir       string
+	resources []*resourceFile
+	// files holds the file each resource, by id, is written to
+	files   map[string]*resourceFile
+	patches []string
+	data    map[string]map[string]string
+}
+
+// resourceFile is a file of kustomize resources, holding one YAML document per resource
+type resourceFile struct {
+	title string
+	docs  []document
+}
+
+type document struct {
+	// id identifies the resource like kustomize does, by group, kind, namespace and name
+	id      string
+	content string
+}
+
+var _ install.Recorder = (*Exporter)(nil)
+
+// New returns an Exporter writing to dir
+func New(dir string) *Exporter {
+	return &Exporter{dir: dir, files: map[string]*resourceFile{}, data: map[string]map[string]string{}}
+}
+
+// Manifest collects the resources of the release manifest
+func (e *Exporter) Manifest(ctx context.Context, m install.Manifest) error {
+	content, err := m.Read(ctx)
+	if err != nil {
+		return err
+	}
+	return e.resource(m.File, content)
+}
+
+// Resources collects the inline resources
+func (e *Exporter) Resources(title, config string) error {
+	return e.resource(title, config)
+}
+
+// ConfigMapPatch collects the patch. Patches of the same ConfigMap are merged.
+func (e *Exporter) ConfigMapPatch(ns, name string, data map[string]string) error {
+	key := ns + "/" + name
+	if _, ok := e.data[key]; !ok {
+		e.patches = append(e.patches, key)
+		e.data[key] = map[string]string{}
+	}
+	maps.Copy(e.data[key], data)
+	return nil
+}
+
+// Step does nothing, changes outside of the cluster are not part of the export
+func (e *Exporter) Step(title, content string) error {
+	return nil
+}
+
+func (e *Exporter) resource(title, content string) error {
+	docs, err := documents(content)
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", title, err)
+	}
+	f := &resourceFile{title: title}
+	for _, d := range docs {
+		if prev, ok := e.files[d.id]; ok {
+			prev.docs = slices.DeleteFunc(prev.docs, func(p document) bool { return p.id == d.id })
+		}
+		e.files[d.id] = f
+		f.docs = append(f.docs, d)
+	}
+	e.resources = append(e.resources, f)
+	return nil
+}
+
+// documents splits the YAML documents of the resources, dropping empty ones
+func documents(content string) ([]document, error) {
+	var docs []document
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(strings.NewReader(content)))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var obj struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string `yaml:"kind"`
+			Metadata   struct {
+				Name      string `yaml:"name"`
+				Namespace string `yaml:"namespace"`
+			} `yaml:"metadata"`
+		}
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			return nil, err
+		}
+		if obj.Kind == "" {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(obj.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+		text := strings.TrimLeft(string(doc), "\n")
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		docs = append(docs, document{
+			id:      strings.Join([]string{gv.Group, obj.Kind, obj.Metadata.Namespace, obj.Metadata.Name}, "/"),
+			content: text,
+		})
+	}
+}
+
+// Close writes the resources, the patches and the kustomization.yaml referencing all
+// files
+func (e *Exporter) Close() error {
+	var b strings.Builder
+	b.WriteString("# Generated by kn quickstart export\n")
+	b.WriteString("apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\n")
+	b.WriteString("resources:\n")
+	n := 0
+	for _, f := range e.resources {
+		if len(f.docs) == 0 {
+			// All its resources were recorded again later
+			continue
+		}
+		n++
+		path := fmt.Sprintf("%s/%02d-%s.yaml", resourcesDir, n, fileName(f.title))
+		var content []string
+		for _, d := range f.docs {
+			content = append(content, d.content)
+		}
+		if err := e.write(filepath.FromSlash(path), strings.Join(content, "---\n")); err != nil {
+			return err
+		}
+		b.WriteString("  - " + path + "\n")
+	}
+	if len(e.patches) > 0 {
+		b.WriteString("patches:\n")
+	}
+	for _, key := range e.patches {
+		ns, name, _ := strings.Cut(key, "/")
+		content, err := dryrun.ConfigMapPatch(ns, name, e.data[key])
+		if err != nil {
+			return err
+		}
+		path := patchesDir + "/" + fileName(ns+"-"+name) + ".yaml"
+		if err := e.write(filepath.FromSlash(path), content); err != nil {
+			return err
+		}
+		b.WriteString("  - path: " + path + "\n")
+	}
+	return e.write("kustomization.yaml", b.String())
+}
+
+func (e *Exporter) write(path, content string) error {
+	path = filepath.Join(e.dir, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	return nil
+}
+
+// fileName turns a title into a file name, e.g. "serving-core.yaml" to "serving-core"
+func fileName(title string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(strings.ReplaceAll(title, " ", "-")), ".yaml"), ".yml")
+}
+
+// Provider is the quickstart.ClusterProvider of an export. There is no cluster, the
+// networking layer is exposed the way it is on kind, or through its LoadBalancer.
+type Provider struct {
+	nodeIP       string
+	loadBalancer bool
+}
+
+var _ quickstart.ClusterProvider = (*Provider)(nil)
+
+// NewProvider returns a Provider exposing the networking layer on NodePort 31080 of
+// nodeIP, or through its LoadBalancer when loadBalancer is true
+func NewProvider(nodeIP string, loadBalancer bool) *Provider {
+	return &Provider{nodeIP: nodeIP, loadBalancer: loadBalancer}
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return "export"
+}
+
+// ClusterName returns an empty name, there is no cluster
+func (p *Provider) ClusterName() string {
+	return ""
+}
+
+// KubeContext returns an empty context, there is no cluster
+func (p *Provider) KubeContext() string {
+	return ""
+}
+
+// Preflight does nothing
+func (p *Provider) Preflight(ctx context.Context) error {
+	return nil
+}
+
+// Exists reports true, there is no cluster to create
+func (p *Provider) Exists(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+// Create does nothing
+func (p *Provider) Create(ctx context.Context) error {
+	return nil
+}
+
+// Delete does nothing
+func (p *Provider) Delete(ctx context.Context) error {
+	return nil
+}
+
+// ConfigureRegistry does nothing, the target clusters bring their own registries
+func (p *Provider) ConfigureRegistry(ctx context.Context) (string, error) {
+	return "", nil
+}
+
+// ConfigureIngress exposes the networking layer
+func (p *Provider) ConfigureIngress(ctx context.Context) error {
+	if p.loadBalancer {
+		return install.Ingress.ExposeLoadBalancer(ctx)
+	}
+	return install.Ingress.ExposeNodePort(ctx, p.nodeIP)
+}
@@ -0,0 +1,123 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestExporter(t *testing.T) {
+	dir := t.TempDir()
+	e := New(dir)
+	assert.NilError(t, e.Resources("kourier-ingress", "\nkind: Service\n"))
+	assert.NilError(t, e.ConfigMapPatch("knative-serving", "config-domain", map[string]string{"a.sslip.io": ""}))
+	assert.NilError(t, e.ConfigMapPatch("knative-serving", "config-domain", map[string]string{"b.sslip.io": ""}))
+	assert.NilError(t, e.Resources("example-broker", "kind: Broker\n"))
+	assert.NilError(t, e.Close())
+
+	kustomization, err := os.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(kustomization), `# Generated by kn quickstart export
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - resources/01-kourier-ingress.yaml
+  - resources/02-example-broker.yaml
+patches:
+  - path: patches/knative-serving-config-domain.yaml
+`)
+
+	service, err := os.ReadFile(filepath.Join(dir, "resources", "01-kourier-ingress.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(service), "kind: Service\n")
+
+	patch, err := os.ReadFile(filepath.Join(dir, "patches", "knative-serving-config-domain.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(patch), `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-domain
+  namespace: knative-serving
+data:
+  a.sslip.io: ""
+  b.sslip.io: ""
+`)
+}
+
+func TestExporterDropsDuplicates(t *testing.T) {
+	crd := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: services.serving.knative.dev
+`
+	dir := t.TempDir()
+	e := New(dir)
+	assert.NilError(t, e.Resources("serving-crds.yaml", crd))
+	assert.NilError(t, e.Resources("serving-core.yaml", crd+`---
+# the namespace of Serving
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: knative-serving
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-domain
+  namespace: knative-serving
+`))
+	assert.NilError(t, e.ConfigMapPatch("knative-serving", "config-domain", map[string]string{"127.0.0.1.sslip.io": ""}))
+	assert.NilError(t, e.Close())
+
+	kustomization, err := os.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(kustomization), `# Generated by kn quickstart export
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - resources/01-serving-core.yaml
+patches:
+  - path: patches/knative-serving-config-domain.yaml
+`)
+
+	// The CRD recorded twice is only kept in the later file, which kustomize requires
+	// to build without duplicate resource ids
+	_, err = os.Stat(filepath.Join(dir, "resources", "01-serving-crds.yaml"))
+	assert.Assert(t, os.IsNotExist(err))
+	core, err := os.ReadFile(filepath.Join(dir, "resources", "01-serving-core.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(core), crd+`---
+# the namespace of Serving
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: knative-serving
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-domain
+  namespace: knative-serving
+`)
+
+	patch, err := os.ReadFile(filepath.Join(dir, "patches", "knative-serving-config-domain.yaml"))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(patch), "  127.0.0.1.sslip.io: \"\"\n"), string(patch))
+}
@@ -0,0 +1,119 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BrokerClass is a broker implementation for Knative Eventing
+type BrokerClass interface {
+	// Name is the name selecting the broker class, e.g. "kafka"
+	Name() string
+	// Class is the value of the eventing.knative.dev/broker.class annotation of the
+	// brokers of this class
+	Class() string
+	// Manifests returns the release manifests of the broker implementation
+	Manifests() []Manifest
+	// Images returns the images of the backing services that are not referenced by
+	// the release manifests
+	Images() []string
+	// Install installs the broker implementation and its backing services, waits for
+	// them to be ready and makes the class the default of the cluster
+	Install(ctx context.Context) error
+}
+
+// Broker is the broker class installed with Eventing
+var Broker BrokerClass = brokerClasses["mt-channel"]
+
+// SetBrokerClass selects the broker class installed with Eventing. An empty name keeps
+// the default, the multi-tenant channel based broker.
+func SetBrokerClass(name string) error {
+	if name == "" {
+		return nil
+	}
+	b, ok := brokerClasses[name]
+	if !ok {
+		return fmt.Errorf("unsupported broker class %q, must be one of: %s", name, strings.Join(BrokerClassNames(), ", "))
+	}
+	Broker = b
+	return nil
+}
+
+// BrokerClassNames returns the names of the supported broker classes
+func BrokerClassNames() []string {
+	names := make([]string, 0, len(brokerClasses))
+	for name := range brokerClasses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var brokerClasses = map[string]BrokerClass{
+	"mt-channel": &mtChannelBroker{},
+	"kafka":      &kafkaBroker{},
+	"rabbitmq":   &rabbitMQBroker{},
+}
+
+// mtChannelBroker is the multi-tenant channel based broker of Knative Eventing, backed
+// by the in-memory channel
+type mtChannelBroker struct{}
+
+func (b *mtChannelBroker) Name() string {
+	return "mt-channel"
+}
+
+func (b *mtChannelBroker) Class() string {
+	return "MTChannelBasedBroker"
+}
+
+func (b *mtChannelBroker) Manifests() []Manifest {
+	return []Manifest{
+		manifest("knative/eventing", EventingVersion, "in-memory-channel.yaml"),
+		manifest("knative/eventing", EventingVersion, "mt-channel-broker.yaml"),
+	}
+}
+
+func (b *mtChannelBroker) Images() []string {
+	return nil
+}
+
+func (b *mtChannelBroker) Install(ctx context.Context) error {
+	manifests := b.Manifests()
+
+	if err := applyManifest(ctx, manifests[0]); err != nil {
+		return fmt.Errorf("wait: %w", err)
+	}
+
+	if err := waitForDeploymentsAvailable(ctx, "knative-eventing"); err != nil {
+		return fmt.Errorf("channel: %w", err)
+	}
+	fmt.Println("    In-memory channel installed...")
+
+	if err := applyManifest(ctx, manifests[1]); err != nil {
+		return fmt.Errorf("wait: %w", err)
+	}
+
+	if err := waitForDeploymentsAvailable(ctx, "knative-eventing"); err != nil {
+		return fmt.Errorf("broker: %w", err)
+	}
+	fmt.Println("    Mt-channel broker installed...")
+
+	return nil
+}
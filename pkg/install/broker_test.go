@@ -0,0 +1,56 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSetBrokerClass(t *testing.T) {
+	t.Cleanup(func() { Broker = brokerClasses["mt-channel"] })
+
+	assert.Equal(t, Broker.Class(), "MTChannelBasedBroker")
+	assert.NilError(t, SetBrokerClass(""))
+	assert.Equal(t, Broker.Name(), "mt-channel")
+
+	EventingKafkaVersion = "1.17.0"
+	t.Cleanup(func() { EventingKafkaVersion = "" })
+	assert.NilError(t, SetBrokerClass("kafka"))
+	assert.Equal(t, Broker.Class(), "Kafka")
+	assert.DeepEqual(t, Broker.Manifests(), []Manifest{
+		{Repo: "knative-extensions/eventing-kafka-broker", Version: "1.17.0", File: "eventing-kafka-controller.yaml"},
+		{Repo: "knative-extensions/eventing-kafka-broker", Version: "1.17.0", File: "eventing-kafka-broker.yaml"},
+	})
+
+	assert.ErrorContains(t, SetBrokerClass("nats"), `unsupported broker class "nats", must be one of: kafka, mt-channel, rabbitmq`)
+}
+
+func TestRabbitMQManifests(t *testing.T) {
+	EventingRabbitMQVersion = "1.17.0"
+	t.Cleanup(func() { EventingRabbitMQVersion = "" })
+
+	var paths []string
+	for _, m := range brokerClasses["rabbitmq"].Manifests() {
+		paths = append(paths, m.Path())
+	}
+	assert.DeepEqual(t, paths, []string{
+		"cert-manager/cert-manager/releases/download/v" + CertManagerVersion + "/cert-manager.yaml",
+		"rabbitmq/cluster-operator/releases/download/v" + RabbitMQClusterOperatorVersion + "/cluster-operator.yml",
+		"rabbitmq/messaging-topology-operator/releases/download/v" + RabbitMQTopologyOperatorVersion + "/messaging-topology-operator-with-certmanager.yaml",
+		"knative-extensions/eventing-rabbitmq/releases/download/knative-v1.17.0/rabbitmq-broker.yaml",
+	})
+}
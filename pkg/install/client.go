@@ -0,0 +1,340 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/ptr"
+)
+
+// UseKubectl makes quickstart apply, patch, wait for and read resources with kubectl
+// instead of the Kubernetes API, as it did before it used the API
+var UseKubectl bool
+
+// Resources quickstart waits for
+var (
+	deploymentsResource      = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	crdsResource             = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	rabbitMQClustersResource = schema.GroupVersionResource{Group: "rabbitmq.com", Version: "v1beta1", Resource: "rabbitmqclusters"}
+)
+
+// apiClient talks to the Kubernetes API of the cluster selected by Kubeconfig and
+// KubeContext
+type apiClient struct {
+	kube    kubernetes.Interface
+	dynamic dynamic.Interface
+	// mapper maps the kinds of applied resources to their API resources
+	mapper meta.RESTMapper
+}
+
+// newAPIClient creates the client for the given kubeconfig and context, which default
+// to kubectl's defaults when empty. Tests replace it with fakes.
+var newAPIClient = func(kubeconfig, kubeContext string) (*apiClient, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load kubeconfig: %w", err)
+	}
+	// Release manifests hold hundreds of resources, do not throttle applying them
+	config.QPS = 50
+	config.Burst = 100
+	config.WarningHandler = rest.NoWarnings{}
+	kube, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Kubernetes client: %w", err)
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Kubernetes client: %w", err)
+	}
+	disc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Kubernetes client: %w", err)
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(disc))
+	return &apiClient{kube: kube, dynamic: dyn, mapper: mapper}, nil
+}
+
+var (
+	client *apiClient
+	// clientFor is the Kubeconfig and KubeContext client was created for
+	clientFor string
+)
+
+// clusterClient returns the client for the cluster selected by Kubeconfig and
+// KubeContext, creating it on first use and when they change
+func clusterClient() (*apiClient, error) {
+	key := Kubeconfig + "\n" + KubeContext
+	if client == nil || clientFor != key {
+		c, err := newAPIClient(Kubeconfig, KubeContext)
+		if err != nil {
+			return nil, err
+		}
+		client, clientFor = c, key
+	}
+	return client, nil
+}
+
+// apply server-side applies the resources of the given YAML documents, in order
+func apply(ctx context.Context, data string) error {
+	c, err := clusterClient()
+	if err != nil {
+		return err
+	}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(strings.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid resources: %w", err)
+		}
+		doc, err = utilyaml.ToJSON(doc)
+		if err != nil {
+			return fmt.Errorf("invalid resources: %w", err)
+		}
+		if doc = bytes.TrimSpace(doc); len(doc) == 0 || string(doc) == "null" {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(doc); err != nil {
+			return fmt.Errorf("invalid resources: %w", err)
+		}
+		if obj.IsList() {
+			err = obj.EachListItem(func(item runtime.Object) error {
+				return c.apply(ctx, item.(*unstructured.Unstructured))
+			})
+		} else {
+			err = c.apply(ctx, obj)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// apply server-side applies the resource with the kn-quickstart field manager, taking
+// over fields from other managers like kubectl's client-side apply
+func (c *apiClient) apply(ctx context.Context, obj *unstructured.Unstructured) error {
+	gvk := obj.GroupVersionKind()
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// The kind may be defined by CRDs created since the API was discovered
+		if m, ok := c.mapper.(meta.ResettableRESTMapper); ok {
+			m.Reset()
+			mapping, err = c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+	var resource dynamic.ResourceInterface = c.dynamic.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ns := obj.GetNamespace()
+		if ns == "" {
+			ns = metav1.NamespaceDefault
+		}
+		resource = c.dynamic.Resource(mapping.Resource).Namespace(ns)
+	}
+	_, err = resource.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: fieldManager, Force: ptr.To(true)})
+	if err != nil {
+		return fmt.Errorf("failed to apply %s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+	return nil
+}
+
+// waitForCondition waits until the resources of gvr in ns, or only the named one, have
+// the given condition, for up to timeout or until the deadline of ctx. Cluster-scoped
+// resources have no ns.
+func waitForCondition(ctx context.Context, gvr schema.GroupVersionResource, ns, name, condition string, timeout time.Duration) error {
+	if DryRun != nil {
+		return nil
+	}
+	timeout = WaitTimeout(ctx, timeout)
+	if UseKubectl {
+		args := []string{"wait", gvr.GroupResource().String()}
+		if name != "" {
+			args = append(args, name)
+		} else {
+			args = append(args, "--all")
+		}
+		if ns != "" {
+			args = append(args, "-n", ns)
+		}
+		args = append(args, "--for=condition="+condition, "--timeout="+timeout.String())
+		return runCommand(Kubectl(ctx, args...))
+	}
+	c, err := clusterClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return c.waitFor(ctx, gvr, ns, name, condition)
+}
+
+// waitFor watches the resources of gvr in ns, or only the named one, until there are
+// some and all of them have the condition, or returns an error once ctx is done
+func (c *apiClient) waitFor(ctx context.Context, gvr schema.GroupVersionResource, ns, name, condition string) error {
+	resource := c.dynamic.Resource(gvr).Namespace(ns)
+	var opts metav1.ListOptions
+	if name != "" {
+		opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+	}
+	// ready holds whether each resource has the condition
+	ready := map[string]bool{}
+	update := func(obj *unstructured.Unstructured, deleted bool) {
+		switch {
+		case name != "" && obj.GetName() != name:
+		case deleted:
+			delete(ready, obj.GetName())
+		default:
+			ready[obj.GetName()] = hasCondition(obj, condition)
+		}
+	}
+	for {
+		list, err := resource.List(ctx, opts)
+		if err != nil {
+			return waitError(ctx, gvr, ns, condition, ready, err)
+		}
+		clear(ready)
+		for i := range list.Items {
+			update(&list.Items[i], false)
+		}
+		if allReady(ready) {
+			return nil
+		}
+
+		watchOpts := opts
+		watchOpts.ResourceVersion = list.GetResourceVersion()
+		w, err := resource.Watch(ctx, watchOpts)
+		if err != nil {
+			return waitError(ctx, gvr, ns, condition, ready, err)
+		}
+		done, err := watchUntilReady(ctx, w, update, ready)
+		w.Stop()
+		if done {
+			return nil
+		}
+		if err != nil {
+			return waitError(ctx, gvr, ns, condition, ready, err)
+		}
+		// The watch ended, e.g. because its resource version expired, list again
+		if err := sleep(ctx, time.Second); err != nil {
+			return waitError(ctx, gvr, ns, condition, ready, err)
+		}
+	}
+}
+
+// watchUntilReady updates ready from the events of w until all resources are ready,
+// the watch ends or ctx is done
+func watchUntilReady(ctx context.Context, w watch.Interface, update func(*unstructured.Unstructured, bool), ready map[string]bool) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case event, ok := <-w.ResultChan():
+			if !ok || event.Type == watch.Error {
+				return false, nil
+			}
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			update(obj, event.Type == watch.Deleted)
+			if allReady(ready) {
+				return true, nil
+			}
+		}
+	}
+}
+
+// waitError explains which resources are not ready when waiting for them failed
+func waitError(ctx context.Context, gvr schema.GroupVersionResource, ns, condition string, ready map[string]bool, err error) error {
+	what := gvr.GroupResource().String()
+	if ns != "" {
+		what += " in namespace " + ns
+	}
+	if ctx.Err() == nil {
+		return fmt.Errorf("failed to watch %s: %w", what, err)
+	}
+	var notReady []string
+	for name, r := range ready {
+		if !r {
+			notReady = append(notReady, name)
+		}
+	}
+	if len(notReady) == 0 {
+		return fmt.Errorf("timed out waiting for %s: none found: %w", what, err)
+	}
+	slices.Sort(notReady)
+	return fmt.Errorf("timed out waiting for the condition %s on %s: %s: %w", condition, what, strings.Join(notReady, ", "), err)
+}
+
+// allReady reports whether there are resources, and all of them are ready
+func allReady(ready map[string]bool) bool {
+	if len(ready) == 0 {
+		return false
+	}
+	for _, r := range ready {
+		if !r {
+			return false
+		}
+	}
+	return true
+}
+
+// hasCondition reports whether the status condition of the given type is True
+func hasCondition(obj *unstructured.Unstructured, conditionType string) bool {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		c, ok := c.(map[string]interface{})
+		if ok && c["type"] == conditionType {
+			return c["status"] == "True"
+		}
+	}
+	return false
+}
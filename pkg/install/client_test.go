@@ -0,0 +1,141 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeClient makes the install functions use fake clients holding the given objects
+func fakeClient(t *testing.T, objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		deploymentsResource: "DeploymentList",
+	}, objects...)
+
+	newClient := newAPIClient
+	t.Cleanup(func() {
+		newAPIClient = newClient
+		client = nil
+	})
+	client = nil
+	newAPIClient = func(string, string) (*apiClient, error) {
+		return &apiClient{kube: kubefake.NewSimpleClientset(), dynamic: dyn, mapper: mapper}, nil
+	}
+	return dyn
+}
+
+func deployment(name string, available bool) *unstructured.Unstructured {
+	status := "False"
+	if available {
+		status = "True"
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "knative-serving"},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{"type": "Available", "status": status}},
+		},
+	}}
+}
+
+func TestApply(t *testing.T) {
+	dyn := fakeClient(t)
+	var applied []string
+	dyn.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		assert.Equal(t, patch.GetPatchType(), types.ApplyPatchType)
+		assert.Equal(t, patch.(k8stesting.PatchActionImpl).PatchOptions.FieldManager, "kn-quickstart")
+		applied = append(applied, patch.GetResource().Resource+" "+patch.GetNamespace()+"/"+patch.GetName())
+		return true, &unstructured.Unstructured{}, nil
+	})
+
+	err := apply(context.Background(), `apiVersion: v1
+kind: Namespace
+metadata:
+  name: knative-serving
+---
+# a comment only document
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-domain
+  namespace: knative-serving
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example
+`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, applied, []string{
+		"namespaces /knative-serving",
+		"configmaps knative-serving/config-domain",
+		"configmaps default/example",
+	})
+
+	err = apply(context.Background(), "apiVersion: example.dev/v1\nkind: Unknown\nmetadata:\n  name: x\n")
+	assert.ErrorContains(t, err, "Unknown x: no matches for kind")
+}
+
+func TestWaitForCondition(t *testing.T) {
+	dyn := fakeClient(t, deployment("activator", true), deployment("webhook", false))
+
+	go func() {
+		// Make the webhook available once the watch started
+		for !watching(dyn) {
+			time.Sleep(time.Millisecond)
+		}
+		_, err := dyn.Resource(deploymentsResource).Namespace("knative-serving").Update(context.Background(), deployment("webhook", true), metav1.UpdateOptions{})
+		assert.Check(t, err)
+	}()
+	assert.NilError(t, waitForCondition(context.Background(), deploymentsResource, "knative-serving", "", "Available", time.Minute))
+
+	fakeClient(t, deployment("activator", true), deployment("webhook", false))
+	err := waitForCondition(context.Background(), deploymentsResource, "knative-serving", "", "Available", 10*time.Millisecond)
+	assert.ErrorContains(t, err, "timed out waiting for the condition Available on deployments.apps in namespace knative-serving: webhook")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	fakeClient(t)
+	err = waitForCondition(context.Background(), deploymentsResource, "knative-eventing", "", "Available", 10*time.Millisecond)
+	assert.ErrorContains(t, err, "timed out waiting for deployments.apps in namespace knative-eventing: none found")
+}
+
+func watching(dyn *dynamicfake.FakeDynamicClient) bool {
+	for _, action := range dyn.Actions() {
+		if action.GetVerb() == "watch" {
+			return true
+		}
+	}
+	return false
+}
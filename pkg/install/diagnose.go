@@ -0,0 +1,329 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// diagnoseTimeout limits collecting a diagnosis, which also runs once the
+// installation ran out of time
+const diagnoseTimeout = 30 * time.Second
+
+// maxEvents and logLines limit the events and log lines of a diagnosis
+const (
+	maxEvents = 10
+	logLines  = 10
+)
+
+func parsePods(out []byte) ([]corev1.Pod, error) {
+	var list corev1.PodList
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("unable to parse pods: %w", err)
+	}
+	return list.Items, nil
+}
+
+func parseEvents(out []byte) ([]corev1.Event, error) {
+	var list corev1.EventList
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("unable to parse events: %w", err)
+	}
+	return list.Items, nil
+}
+
+// diagnosis explains why the pods of a namespace are not ready
+type diagnosis struct {
+	namespace string
+	// problems lists the non-ready pods, with the reasons of their containers
+	problems []string
+	events   []string
+	// crashing are the pod/container names of containers that restarted, whose
+	// logs are collected
+	crashing []string
+	logs     map[string][]string
+	causes   []string
+}
+
+// Likely causes of pods that are not ready
+const (
+	causeRateLimit   = "the Docker Hub pull rate limit was reached, wait for it to reset or let the cluster pull images as a logged in Docker Hub user"
+	causeImagePull   = "images cannot be pulled, check the network access and proxy settings of the cluster"
+	causeMemory      = "containers ran out of memory, give the cluster, or the Docker or VM it runs in, more memory"
+	causeResources   = "the cluster does not have enough CPU or memory for the pods, give it more or remove other workloads"
+	causeUnscheduled = "pods cannot be scheduled, see the events"
+	causeCrashing    = "containers keep crashing, see their logs"
+	causeConfig      = "a ConfigMap or Secret the pods need is missing or invalid"
+)
+
+// analyze finds the pods that are not ready, their problems and their likely causes
+func analyze(ns string, pods []corev1.Pod, events []corev1.Event) diagnosis {
+	d := diagnosis{namespace: ns}
+	notReady := map[string]bool{}
+	var reasons []string
+	for _, p := range pods {
+		if p.Status.Phase == corev1.PodSucceeded || conditionStatus(p.Status.Conditions, corev1.PodReady) == corev1.ConditionTrue {
+			continue
+		}
+		notReady[p.Name] = true
+		var problems []string
+		if c := findCondition(p.Status.Conditions, corev1.PodScheduled); c != nil && c.Status == corev1.ConditionFalse {
+			problems = append(problems, c.Reason+": "+c.Message)
+			if strings.Contains(c.Message, "Insufficient cpu") || strings.Contains(c.Message, "Insufficient memory") {
+				reasons = append(reasons, "Insufficient")
+			} else {
+				reasons = append(reasons, c.Reason)
+			}
+		}
+		for _, cs := range slices.Concat(p.Status.InitContainerStatuses, p.Status.ContainerStatuses) {
+			if cs.Ready {
+				continue
+			}
+			problem, reason := containerProblem(cs)
+			if problem == "" {
+				continue
+			}
+			problems = append(problems, "container "+cs.Name+": "+problem)
+			reasons = append(reasons, reason)
+			if cs.RestartCount > 0 {
+				d.crashing = append(d.crashing, p.Name+"/"+cs.Name)
+			}
+		}
+		if len(problems) == 0 {
+			problems = append(problems, "phase "+string(p.Status.Phase))
+		}
+		d.problems = append(d.problems, "Pod "+p.Name+" is not ready: "+strings.Join(problems, "; "))
+	}
+
+	var messages []string
+	for _, e := range events {
+		if !notReady[e.InvolvedObject.Name] {
+			continue
+		}
+		d.events = append(d.events, e.InvolvedObject.Name+": "+e.Reason+": "+e.Message)
+		messages = append(messages, e.Message)
+	}
+	if len(d.events) > maxEvents {
+		d.events = d.events[len(d.events)-maxEvents:]
+	}
+
+	for _, reason := range reasons {
+		switch reason {
+		case "ErrImagePull", "ImagePullBackOff":
+			d.addCause(causeImagePull)
+		case "OOMKilled":
+			d.addCause(causeMemory)
+		case "Insufficient":
+			d.addCause(causeResources)
+		case "Unschedulable":
+			d.addCause(causeUnscheduled)
+		case "CrashLoopBackOff", "Error":
+			d.addCause(causeCrashing)
+		case "CreateContainerConfigError":
+			d.addCause(causeConfig)
+		}
+	}
+	if slices.Contains(d.causes, causeImagePull) && slices.ContainsFunc(slices.Concat(d.problems, messages), rateLimited) {
+		d.causes[slices.Index(d.causes, causeImagePull)] = causeRateLimit
+	}
+	return d
+}
+
+// containerProblem returns why the container is not ready, and the reason used to
+// find the likely cause, or "" if it is simply starting
+func containerProblem(cs corev1.ContainerStatus) (problem, reason string) {
+	if w := cs.State.Waiting; w != nil && w.Reason != "" && w.Reason != "ContainerCreating" && w.Reason != "PodInitializing" {
+		problem, reason = w.Reason, w.Reason
+		if w.Message != "" {
+			problem += ": " + w.Message
+		}
+	}
+	for _, t := range []*corev1.ContainerStateTerminated{cs.State.Terminated, cs.LastTerminationState.Terminated} {
+		if t == nil || t.Reason == "Completed" {
+			continue
+		}
+		last := fmt.Sprintf("last terminated with %s (exit code %d)", t.Reason, t.ExitCode)
+		if problem == "" {
+			problem, reason = last, t.Reason
+		} else {
+			problem += ", " + last
+		}
+		if t.Reason == "OOMKilled" {
+			// Containers killed for memory crash loop, their logs do not tell more
+			reason = t.Reason
+		}
+		break
+	}
+	if problem != "" && cs.RestartCount > 0 {
+		problem += fmt.Sprintf(", %d restarts", cs.RestartCount)
+	}
+	return problem, reason
+}
+
+// rateLimited reports whether the message is about the Docker Hub pull rate limit
+func rateLimited(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "toomanyrequests") || strings.Contains(message, "rate limit")
+}
+
+func (d *diagnosis) addCause(cause string) {
+	if !slices.Contains(d.causes, cause) {
+		d.causes = append(d.causes, cause)
+	}
+}
+
+func findCondition(conditions []corev1.PodCondition, conditionType corev1.PodConditionType) *corev1.PodCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+func conditionStatus(conditions []corev1.PodCondition, conditionType corev1.PodConditionType) corev1.ConditionStatus {
+	if c := findCondition(conditions, conditionType); c != nil {
+		return c.Status
+	}
+	return ""
+}
+
+// print writes the diagnosis to w
+func (d diagnosis) print(w io.Writer) {
+	fmt.Fprintln(w, "🩺 Diagnosing namespace "+d.namespace+"...")
+	if len(d.problems) == 0 {
+		fmt.Fprintln(w, "    No pod has a problem yet, see 'kubectl get deployments,pods -n "+d.namespace+"'")
+		return
+	}
+	for _, p := range d.problems {
+		fmt.Fprintln(w, "    "+p)
+	}
+	if len(d.events) > 0 {
+		fmt.Fprintln(w, "    Recent events:")
+		for _, e := range d.events {
+			fmt.Fprintln(w, "      "+e)
+		}
+	}
+	for _, c := range d.crashing {
+		if len(d.logs[c]) == 0 {
+			continue
+		}
+		fmt.Fprintln(w, "    Last log lines of "+c+":")
+		for _, line := range d.logs[c] {
+			fmt.Fprintln(w, "      "+line)
+		}
+	}
+	if len(d.causes) > 0 {
+		fmt.Fprintln(w, "    Likely causes:")
+		for _, c := range d.causes {
+			fmt.Fprintln(w, "      - "+c)
+		}
+	}
+}
+
+// diagnose collects the pods of the namespace that are not ready, their container
+// statuses, their recent warning events and the last log lines of their crashing
+// containers, and prints why they are not ready with the likely causes. It runs after
+// waiting for the namespace failed, also when ctx is done because the installation
+// ran out of time, but not when it was interrupted.
+func diagnose(ctx context.Context, ns string) {
+	if DryRun != nil || errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), diagnoseTimeout)
+	defer cancel()
+
+	pods, events, err := podsAndEvents(ctx, ns)
+	if err != nil {
+		return
+	}
+	d := analyze(ns, pods, events)
+	d.logs = map[string][]string{}
+	for _, c := range d.crashing {
+		pod, container, _ := strings.Cut(c, "/")
+		if logs := strings.TrimSpace(previousLogs(ctx, ns, pod, container)); logs != "" {
+			d.logs[c] = strings.Split(logs, "\n")
+		}
+	}
+	d.print(os.Stdout)
+}
+
+// podsAndEvents returns the pods of the namespace, and its warning events from the
+// oldest to the most recent
+func podsAndEvents(ctx context.Context, ns string) ([]corev1.Pod, []corev1.Event, error) {
+	if UseKubectl {
+		out, err := Kubectl(ctx, "get", "pods", "-n", ns, "-o", "json").Output()
+		if err != nil {
+			return nil, nil, err
+		}
+		pods, err := parsePods(out)
+		if err != nil {
+			return nil, nil, err
+		}
+		var events []corev1.Event
+		if out, err := Kubectl(ctx, "get", "events", "-n", ns, "--field-selector", "type=Warning", "--sort-by=.lastTimestamp", "-o", "json").Output(); err == nil {
+			events, _ = parseEvents(out)
+		}
+		return pods, events, nil
+	}
+	c, err := clusterClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	pods, err := c.kube.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	var events []corev1.Event
+	if list, err := c.kube.CoreV1().Events(ns).List(ctx, metav1.ListOptions{FieldSelector: "type=Warning"}); err == nil {
+		events = list.Items
+		slices.SortStableFunc(events, func(a, b corev1.Event) int {
+			return a.LastTimestamp.Compare(b.LastTimestamp.Time)
+		})
+	}
+	return pods.Items, events, nil
+}
+
+// previousLogs returns the last log lines of the previous run of the container
+func previousLogs(ctx context.Context, ns, pod, container string) string {
+	if UseKubectl {
+		out, err := Kubectl(ctx, "logs", pod, "-c", container, "-n", ns, "--previous", fmt.Sprintf("--tail=%d", logLines)).Output()
+		if err != nil {
+			return ""
+		}
+		return string(out)
+	}
+	c, err := clusterClient()
+	if err != nil {
+		return ""
+	}
+	out, err := c.kube.CoreV1().Pods(ns).GetLogs(pod, &corev1.PodLogOptions{Container: container, Previous: true, TailLines: ptr.To[int64](logLines)}).DoRaw(ctx)
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
@@ -0,0 +1,78 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+const podsJSON = `{"items": [
+  {"metadata": {"name": "controller-1"}, "status": {"phase": "Running",
+    "conditions": [{"type": "Ready", "status": "True"}],
+    "containerStatuses": [{"name": "controller", "ready": true, "state": {"running": {}}}]}},
+  {"metadata": {"name": "kafka-0"}, "status": {"phase": "Pending",
+    "conditions": [{"type": "Ready", "status": "False"}],
+    "containerStatuses": [{"name": "kafka", "ready": false,
+      "state": {"waiting": {"reason": "ImagePullBackOff", "message": "Back-off pulling image \"apache/kafka\""}}}]}},
+  {"metadata": {"name": "webhook-1"}, "status": {"phase": "Running",
+    "conditions": [{"type": "Ready", "status": "False"}],
+    "containerStatuses": [{"name": "webhook", "ready": false, "restartCount": 4,
+      "state": {"waiting": {"reason": "CrashLoopBackOff"}},
+      "lastState": {"terminated": {"reason": "OOMKilled", "exitCode": 137}}}]}},
+  {"metadata": {"name": "activator-1"}, "status": {"phase": "Pending",
+    "conditions": [{"type": "PodScheduled", "status": "False", "reason": "Unschedulable",
+      "message": "0/1 nodes are available: 1 Insufficient memory."}]}}
+]}`
+
+const eventsJSON = `{"items": [
+  {"involvedObject": {"name": "controller-1"}, "reason": "Unhealthy", "message": "Readiness probe failed"},
+  {"involvedObject": {"name": "kafka-0"}, "reason": "Failed", "message": "Failed to pull image \"apache/kafka\": 429 Too Many Requests - Server message: toomanyrequests: You have reached your pull rate limit."}
+]}`
+
+func TestDiagnosis(t *testing.T) {
+	pods, err := parsePods([]byte(podsJSON))
+	assert.NilError(t, err)
+	events, err := parseEvents([]byte(eventsJSON))
+	assert.NilError(t, err)
+
+	d := analyze("knative-eventing", pods, events)
+	assert.DeepEqual(t, d.problems, []string{
+		`Pod kafka-0 is not ready: container kafka: ImagePullBackOff: Back-off pulling image "apache/kafka"`,
+		"Pod webhook-1 is not ready: container webhook: CrashLoopBackOff, last terminated with OOMKilled (exit code 137), 4 restarts",
+		"Pod activator-1 is not ready: Unschedulable: 0/1 nodes are available: 1 Insufficient memory.",
+	})
+	assert.DeepEqual(t, d.crashing, []string{"webhook-1/webhook"})
+	assert.DeepEqual(t, d.causes, []string{causeRateLimit, causeMemory, causeResources})
+
+	d.logs = map[string][]string{"webhook-1/webhook": {"starting webhook"}}
+	var out bytes.Buffer
+	d.print(&out)
+	assert.Equal(t, out.String(), `🩺 Diagnosing namespace knative-eventing...
+    Pod kafka-0 is not ready: container kafka: ImagePullBackOff: Back-off pulling image "apache/kafka"
+    Pod webhook-1 is not ready: container webhook: CrashLoopBackOff, last terminated with OOMKilled (exit code 137), 4 restarts
+    Pod activator-1 is not ready: Unschedulable: 0/1 nodes are available: 1 Insufficient memory.
+    Recent events:
+      kafka-0: Failed: Failed to pull image "apache/kafka": 429 Too Many Requests - Server message: toomanyrequests: You have reached your pull rate limit.
+    Last log lines of webhook-1/webhook:
+      starting webhook
+    Likely causes:
+      - `+causeRateLimit+`
+      - `+causeMemory+`
+      - `+causeResources+`
+`)
+}
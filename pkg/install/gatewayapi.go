@@ -0,0 +1,225 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NetGatewayAPIVersion is generated at buildtime via the hack/build.sh script
+var NetGatewayAPIVersion string
+
+// NOTE: GatewayAPIVersion must be supported by both NetGatewayAPIVersion and
+// EnvoyGatewayVersion.
+var (
+	GatewayAPIVersion   = "1.2.1"
+	EnvoyGatewayVersion = "1.2.4"
+)
+
+// EnvoyProxyImage is the image of the Envoy proxies of the Gateways. It is pinned to
+// the default of EnvoyGatewayVersion, so that it can be bundled for offline installs.
+var EnvoyProxyImage = "docker.io/envoyproxy/envoy:distroless-v1.32.3"
+
+const (
+	gatewayNamespace      = "envoy-gateway-system"
+	externalGateway       = "knative-external"
+	localGateway          = "knative-local"
+	gatewayClass          = "knative-envoy"
+	envoyGatewayProxyPort = 10080 // Envoy Gateway listens on port+10000 for ports below 1024
+)
+
+// gatewayResources returns the GatewayClass and the external and cluster-local Gateways
+// used by net-gateway-api. The EnvoyProxy resources give the Envoy services fixed names,
+// so that they can be referenced from config-gateway, and pin the proxy image.
+func gatewayResources() string {
+	return fmt.Sprintf(`apiVersion: gateway.networking.k8s.io/v1
+kind: GatewayClass
+metadata:
+  name: %s
+spec:
+  controllerName: gateway.envoyproxy.io/gatewayclass-controller
+`, gatewayClass) + gatewayResource(externalGateway) + gatewayResource(localGateway)
+}
+
+func gatewayResource(name string) string {
+	return fmt.Sprintf(`---
+apiVersion: gateway.envoyproxy.io/v1alpha1
+kind: EnvoyProxy
+metadata:
+  name: %[3]s
+  namespace: %[2]s
+spec:
+  provider:
+    type: Kubernetes
+    kubernetes:
+      envoyService:
+        name: %[3]s
+      envoyDeployment:
+        container:
+          image: %[4]s
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: Gateway
+metadata:
+  name: %[3]s
+  namespace: %[2]s
+spec:
+  gatewayClassName: %[1]s
+  infrastructure:
+    parametersRef:
+      group: gateway.envoyproxy.io
+      kind: EnvoyProxy
+      name: %[3]s
+  listeners:
+    - name: http
+      port: 80
+      protocol: HTTP
+      allowedRoutes:
+        namespaces:
+          from: All
+`, gatewayClass, gatewayNamespace, name, EnvoyProxyImage)
+}
+
+// gatewayAPI is the Networking using net-gateway-api, with Envoy Gateway as the
+// Gateway API implementation
+type gatewayAPI struct{}
+
+var externalGatewayService = gateway{
+	namespace: gatewayNamespace,
+	service:   externalGateway,
+	selector: map[string]string{
+		"gateway.envoyproxy.io/owning-gateway-name":      externalGateway,
+		"gateway.envoyproxy.io/owning-gateway-namespace": gatewayNamespace,
+	},
+}
+
+func (g *gatewayAPI) Name() string {
+	return "gateway-api"
+}
+
+func (g *gatewayAPI) Manifests() []Manifest {
+	return []Manifest{
+		{Repo: "kubernetes-sigs/gateway-api", Version: GatewayAPIVersion, File: "standard-install.yaml", Tag: "v" + GatewayAPIVersion},
+		{Repo: "envoyproxy/gateway", Version: EnvoyGatewayVersion, File: "install.yaml", Tag: "v" + EnvoyGatewayVersion},
+		manifest("knative-extensions/net-gateway-api", NetGatewayAPIVersion, "net-gateway-api.yaml"),
+	}
+}
+
+func (g *gatewayAPI) Images() []string {
+	return []string{EnvoyProxyImage}
+}
+
+func (g *gatewayAPI) Namespaces() []string {
+	return []string{gatewayNamespace, "knative-serving"}
+}
+
+func (g *gatewayAPI) Install(ctx context.Context) error {
+	manifests := g.Manifests()
+
+	fmt.Println("🕸️ Installing Gateway API v" + GatewayAPIVersion + " CRDs ...")
+	if err := applyManifest(ctx, manifests[0]); err != nil {
+		return fmt.Errorf("wait: %w", err)
+	}
+	if err := waitForCRDsEstablished(ctx); err != nil {
+		return fmt.Errorf("crds: %w", err)
+	}
+	fmt.Println("    CRDs installed...")
+
+	fmt.Println("🕸️ Installing Envoy Gateway v" + EnvoyGatewayVersion + " ...")
+	if err := applyManifest(ctx, manifests[1]); err != nil {
+		return fmt.Errorf("wait: %w", err)
+	}
+	if err := waitForCRDsEstablished(ctx); err != nil {
+		return fmt.Errorf("crds: %w", err)
+	}
+	if err := waitForDeploymentsAvailable(ctx, gatewayNamespace); err != nil {
+		return fmt.Errorf("envoy gateway: %w", err)
+	}
+	fmt.Println("    Envoy Gateway installed...")
+
+	if err := retryingApplyConfig(ctx, "gateways", gatewayResources()); err != nil {
+		return fmt.Errorf("gateways: %w", err)
+	}
+	fmt.Println("    Gateways created...")
+
+	fmt.Println("🕸️ Installing net-gateway-api v" + NetGatewayAPIVersion + " ...")
+	if err := applyManifest(ctx, manifests[2]); err != nil {
+		return fmt.Errorf("wait: %w", err)
+	}
+	if err := waitForDeploymentsAvailable(ctx, "knative-serving"); err != nil {
+		return fmt.Errorf("serving: %w", err)
+	}
+	if err := waitForDeploymentsAvailable(ctx, gatewayNamespace); err != nil {
+		return fmt.Errorf("gateways: %w", err)
+	}
+	fmt.Println("    net-gateway-api installed...")
+
+	if err := PatchConfigMap(ctx, "knative-serving", "config-gateway", map[string]string{
+		"external-gateways": gatewayConfig(externalGateway),
+		"local-gateways":    gatewayConfig(localGateway),
+	}); err != nil {
+		return fmt.Errorf("gateway config: %w", err)
+	}
+	if err := PatchConfigMap(ctx, "knative-serving", "config-network", map[string]string{"ingress.class": "gateway-api.ingress.networking.knative.dev"}); err != nil {
+		return fmt.Errorf("ingress error: %w", err)
+	}
+	fmt.Println("    Ingress patched...")
+
+	fmt.Println("    Finished installing Gateway API Networking layer")
+
+	return nil
+}
+
+func (g *gatewayAPI) ExposeNodePort(ctx context.Context, nodeIP string) error {
+	return exposeNodePort(ctx, g.Name(), "Gateway API", externalGatewayService, envoyGatewayProxyPort, nodeIP)
+}
+
+// ExposeLoadBalancer sets up the sslip.io domain for the external gateway address. The
+// serving-default-domain job does not know about Envoy Gateway services.
+func (g *gatewayAPI) ExposeLoadBalancer(ctx context.Context) error {
+	fmt.Println("🕸️ Configuring Gateway API LoadBalancer...")
+	address := g.LoadBalancerAddress(ctx, 5*time.Minute)
+	if address == "" {
+		return fmt.Errorf("no address was assigned to service %s/%s", gatewayNamespace, externalGateway)
+	}
+	if err := ConfigureDomain(ctx, address+".sslip.io"); err != nil {
+		return err
+	}
+	fmt.Println("    Finished configuring Gateway API")
+	return nil
+}
+
+func (g *gatewayAPI) LoadBalancerAddress(ctx context.Context, timeout time.Duration) string {
+	return loadBalancerAddress(ctx, externalGatewayService, timeout)
+}
+
+// gatewayConfig returns the config-gateway entry for one of the Gateways
+func gatewayConfig(name string) string {
+	ref := gatewayNamespace + "/" + name
+	return fmt.Sprintf("- class: %s\n  gateway: %s\n  service: %s\n", gatewayClass, ref, ref)
+}
+
+// retryingApplyConfig retries a server-side apply of the given resources, backing off
+// between the tries per RetryBackoff, e.g. while the webhooks of new CRDs start
+func retryingApplyConfig(ctx context.Context, title, config string) error {
+	if DryRun != nil {
+		return DryRun.Resources(title, config)
+	}
+	return retry(ctx, func() error {
+		return applyConfig(ctx, title, config)
+	})
+}
@@ -15,257 +15,330 @@
 package install
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"strings"
 	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 )
 
 // Component versions are generated at buildtime via the hack/build.sh script
 var ServingVersion string
 var KourierVersion string
 var EventingVersion string
-
-// Kourier installs Kourier networking layer from Github YAML files
-func Kourier() error {
-	fmt.Println("🕸️ Installing Kourier networking layer v" + KourierVersion + " ...")
-
-	if err := retryingApply("https://github.com/knative-sandbox/net-kourier/releases/download/knative-v" + KourierVersion + "/kourier.yaml"); err != nil {
-		return fmt.Errorf("wait: %w", err)
-	}
-	if err := waitForPodsReady("kourier-system"); err != nil {
-		return fmt.Errorf("kourier: %w", err)
-	}
-	if err := waitForPodsReady("knative-serving"); err != nil {
-		return fmt.Errorf("serving: %w", err)
+var ContourVersion string
+var IstioVersion string
+
+// Kubeconfig and KubeContext select the cluster the components are installed on, for
+// the Kubernetes API client and every kubectl invocation. When empty, kubectl's
+// defaults are used.
+var Kubeconfig string
+var KubeContext string
+
+// Serving installs Knative Serving from the release manifests
+func Serving(ctx context.Context, registries string) error {
+	if err := ServingCRDs(ctx); err != nil {
+		return err
 	}
-	fmt.Println("    Kourier installed...")
-
-	ingress := exec.Command("kubectl", "patch", "configmap/config-network", "--namespace", "knative-serving", "--type", "merge", "--patch", "{\"data\":{\"ingress.class\":\"kourier.ingress.networking.knative.dev\"}}")
-	if err := runCommand(ingress); err != nil {
-		return fmt.Errorf("ingress error: %w", err)
+	if err := ServingCore(ctx); err != nil {
+		return err
 	}
-	fmt.Println("    Ingress patched...")
-
-	fmt.Println("    Finished installing Kourier Networking layer")
-
-	return nil
-}
-
-// KourierKind runs the kind-specific setup for Kourier
-func KourierKind() error {
-	fmt.Println("🕸️ Configuring Kourier for Kind...")
-
-	config := `apiVersion: v1
-kind: Service
-metadata:
-  name: kourier-ingress
-  namespace: kourier-system
-  labels:
-    networking.knative.dev/ingress-provider: kourier
-spec:
-  type: NodePort
-  selector:
-    app: 3scale-kourier-gateway
-  ports:
-    - name: http2
-      nodePort: 31080
-      port: 80
-      targetPort: 8080`
-
-	kourierIngress := exec.Command("kubectl", "apply", "-f", "-")
-	kourierIngress.Stdin = strings.NewReader(config)
-	if err := runCommand(kourierIngress); err != nil {
-		return fmt.Errorf("kourier service: %w", err)
-	}
-
-	fmt.Println("    Kourier service installed...")
-
-	domainDns := exec.Command("kubectl", "patch", "configmap", "-n", "knative-serving", "config-domain", "-p", "{\"data\": {\"127.0.0.1.sslip.io\": \"\"}}")
-	if err := runCommand(domainDns); err != nil {
-		return fmt.Errorf("domain dns: %w", err)
-	}
-	fmt.Println("    Domain DNS set up...")
-	fmt.Println("    Finished configuring Kourier")
-
-	return nil
-}
-
-// KourierMinikube runs the minikube-specific setup for Kourier
-func KourierMinikube() error {
-	fmt.Println("🕸️ Configuring Kourier for Minikube...")
-
-	if err := retryingApply("https://github.com/knative/serving/releases/download/knative-v" + ServingVersion + "/serving-default-domain.yaml"); err != nil {
-		return fmt.Errorf("default domain: %w", err)
+	if err := WaitForServingWebhook(ctx); err != nil {
+		return err
 	}
-	if err := waitForPodsReady("knative-serving"); err != nil {
-		return fmt.Errorf("core: %w", err)
+	if err := ConfigureRegistries(ctx, registries); err != nil {
+		return err
 	}
-
-	fmt.Println("    Domain DNS set up...")
-
-	fmt.Println("    Finished configuring Kourier")
+	fmt.Println("    Finished installing Knative Serving")
 	return nil
 }
 
-// Serving installs Knative Serving from Github YAML files
-func Serving(registries string) error {
+// ServingCRDs installs the Knative Serving CRDs and waits for them to be established
+func ServingCRDs(ctx context.Context) error {
 	fmt.Println("🍿 Installing Knative Serving v" + ServingVersion + " ...")
-	baseURL := "https://github.com/knative/serving/releases/download/knative-v" + ServingVersion
 
-	if err := retryingApply(baseURL + "/serving-crds.yaml"); err != nil {
+	if err := applyManifest(ctx, manifest("knative/serving", ServingVersion, "serving-crds.yaml")); err != nil {
 		return fmt.Errorf("wait: %w", err)
 	}
 
-	if err := waitForCRDsEstablished(); err != nil {
+	if err := waitForCRDsEstablished(ctx); err != nil {
 		return fmt.Errorf("crds: %w", err)
 	}
 	fmt.Println("    CRDs installed...")
+	return nil
+}
 
-	if err := retryingApply(baseURL + "/serving-core.yaml"); err != nil {
+// ServingCore installs Knative Serving core and waits for its deployments
+func ServingCore(ctx context.Context) error {
+	if err := applyManifest(ctx, manifest("knative/serving", ServingVersion, "serving-core.yaml")); err != nil {
 		return fmt.Errorf("wait: %w", err)
 	}
 
-	if err := waitForPodsReady("knative-serving"); err != nil {
+	if err := waitForDeploymentsAvailable(ctx, "knative-serving"); err != nil {
 		return fmt.Errorf("core: %w", err)
 	}
 
 	fmt.Println("    Core installed...")
+	return nil
+}
 
-	// Wait for webhook to be ready before attempting to patch configmaps
-	if err := waitForWebhookReady(); err != nil {
+// WaitForServingWebhook waits for the Serving webhook to accept requests, which it
+// must before the Serving configmaps can be patched
+func WaitForServingWebhook(ctx context.Context) error {
+	if err := waitForWebhookReady(ctx); err != nil {
 		return fmt.Errorf("webhook: %w", err)
 	}
+	return nil
+}
 
-	if registries != "" {
-		configPatch := fmt.Sprintf(`{"data":{"registries-skipping-tag-resolving":"%s"}}`, registries)
-		ignoreRegistry := exec.Command("kubectl", "patch", "configmap", "-n", "knative-serving", "config-deployment", "-p", configPatch)
-		if err := runCommand(ignoreRegistry); err != nil {
-			return fmt.Errorf("tag resolving configuration: %w", err)
-		}
-		fmt.Println("    Enabled local registry deployment...")
+// ConfigureRegistries makes Serving skip tag resolution for the given registries, if any
+func ConfigureRegistries(ctx context.Context, registries string) error {
+	if registries == "" {
+		return nil
 	}
+	if err := PatchConfigMap(ctx, "knative-serving", "config-deployment", map[string]string{"registries-skipping-tag-resolving": registries}); err != nil {
+		return fmt.Errorf("tag resolving configuration: %w", err)
+	}
+	fmt.Println("    Enabled local registry deployment...")
+	return nil
+}
 
-	fmt.Println("    Finished installing Knative Serving")
-
+// Eventing installs Knative Eventing from the release manifests
+func Eventing(ctx context.Context) error {
+	if err := EventingCRDs(ctx); err != nil {
+		return err
+	}
+	if err := EventingCore(ctx); err != nil {
+		return err
+	}
+	if err := InstallBroker(ctx); err != nil {
+		return err
+	}
+	if err := ExampleBroker(ctx); err != nil {
+		return err
+	}
+	fmt.Println("    Finished installing Knative Eventing")
 	return nil
 }
 
-// Eventing installs Knative Eventing from Github YAML files
-func Eventing() error {
+// EventingCRDs installs the Knative Eventing CRDs and waits for them to be established
+func EventingCRDs(ctx context.Context) error {
 	fmt.Println("🔥 Installing Knative Eventing v" + EventingVersion + " ... ")
-	baseURL := "https://github.com/knative/eventing/releases/download/knative-v" + EventingVersion
 
-	if err := retryingApply(baseURL + "/eventing-crds.yaml"); err != nil {
+	if err := applyManifest(ctx, manifest("knative/eventing", EventingVersion, "eventing-crds.yaml")); err != nil {
 		return fmt.Errorf("wait: %w", err)
 	}
 
-	if err := waitForCRDsEstablished(); err != nil {
+	if err := waitForCRDsEstablished(ctx); err != nil {
 		return fmt.Errorf("crds: %w", err)
 	}
 	fmt.Println("    CRDs installed...")
+	return nil
+}
 
-	if err := retryingApply(baseURL + "/eventing-core.yaml"); err != nil {
+// EventingCore installs Knative Eventing core and waits for its deployments
+func EventingCore(ctx context.Context) error {
+	if err := applyManifest(ctx, manifest("knative/eventing", EventingVersion, "eventing-core.yaml")); err != nil {
 		return fmt.Errorf("wait: %w", err)
 	}
 
-	if err := waitForPodsReady("knative-eventing"); err != nil {
+	if err := waitForDeploymentsAvailable(ctx, "knative-eventing"); err != nil {
 		return fmt.Errorf("core: %w", err)
 	}
 	fmt.Println("    Core installed...")
+	return nil
+}
 
-	if err := retryingApply(baseURL + "/in-memory-channel.yaml"); err != nil {
-		return fmt.Errorf("wait: %w", err)
-	}
-
-	if err := waitForPodsReady("knative-eventing"); err != nil {
-		return fmt.Errorf("channel: %w", err)
-	}
-	fmt.Println("    In-memory channel installed...")
-
-	if err := retryingApply(baseURL + "/mt-channel-broker.yaml"); err != nil {
-		return fmt.Errorf("wait: %w", err)
-	}
-
-	if err := waitForPodsReady("knative-eventing"); err != nil {
-		return fmt.Errorf("broker: %w", err)
+// InstallBroker installs the selected broker class, Broker
+func InstallBroker(ctx context.Context) error {
+	if err := Broker.Install(ctx); err != nil {
+		return fmt.Errorf("%s broker: %w", Broker.Name(), err)
 	}
-	fmt.Println("    Mt-channel broker installed...")
+	return nil
+}
 
-	config := `apiVersion: eventing.knative.dev/v1
-kind: broker
+// ExampleBroker creates the example-broker in the default namespace
+func ExampleBroker(ctx context.Context) error {
+	config := fmt.Sprintf(`apiVersion: eventing.knative.dev/v1
+kind: Broker
 metadata:
  name: example-broker
- namespace: default`
+ namespace: default
+ annotations:
+  eventing.knative.dev/broker.class: %s`, Broker.Class())
 
-	exampleBroker := exec.Command("kubectl", "apply", "-f", "-")
-	exampleBroker.Stdin = strings.NewReader(config)
-	if err := runCommand(exampleBroker); err != nil {
+	if err := applyConfig(ctx, "example-broker", config); err != nil {
 		return fmt.Errorf("example broker: %w", err)
 	}
 
 	fmt.Println("    Example broker installed...")
-	fmt.Println("    Finished installing Knative Eventing")
-
 	return nil
 }
 
+// Kubectl returns a kubectl command pinned to the cluster selected by Kubeconfig and
+// KubeContext
+func Kubectl(ctx context.Context, args ...string) *exec.Cmd {
+	var flags []string
+	if Kubeconfig != "" {
+		flags = append(flags, "--kubeconfig", Kubeconfig)
+	}
+	if KubeContext != "" {
+		flags = append(flags, "--context", KubeContext)
+	}
+	return exec.CommandContext(ctx, "kubectl", append(flags, args...)...)
+}
+
+// runCommand runs the command, and returns its output as part of the error if it fails
 func runCommand(c *exec.Cmd) error {
+	if DryRun != nil {
+		return nil
+	}
 	if out, err := c.CombinedOutput(); err != nil {
-		fmt.Println(string(out))
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
 		return err
 	}
 	return nil
 }
 
-// retryingApply retries a kubectl apply call with the given path 3 times, sleeping
-// for 10s between each try.
-func retryingApply(path string) error {
-	cmd := exec.Command("kubectl", "apply", "-f", path)
-	var err error
-	for i := 0; i < 3; i++ {
-		err = runCommand(cmd)
-		if err == nil {
-			break
-		}
-		time.Sleep(10 * time.Second)
+// retryingApply retries a server-side apply of the given path with kubectl, backing
+// off between the tries per RetryBackoff.
+func retryingApply(ctx context.Context, path string) error {
+	return retry(ctx, func() error {
+		return runCommand(Kubectl(ctx, applyArgs(path)...))
+	})
+}
+
+// applyArgs returns the kubectl arguments to server-side apply the given path. Server-side
+// apply does not store the last applied configuration in an annotation, so large CRDs do
+// not hit the annotation size limit, and it takes over fields from earlier client-side
+// applies.
+func applyArgs(path string) []string {
+	return []string{"apply", "--server-side", "--force-conflicts", "--field-manager=" + fieldManager, "-f", path}
+}
+
+// fieldManager is the server-side apply field manager owning the installed resources
+const fieldManager = "kn-quickstart"
+
+// PatchConfigMap merges the given keys into the data of a ConfigMap
+func PatchConfigMap(ctx context.Context, ns, name string, data map[string]string) error {
+	if DryRun != nil {
+		return DryRun.ConfigMapPatch(ns, name, data)
+	}
+	patch, err := json.Marshal(map[string]map[string]string{"data": data})
+	if err != nil {
+		return fmt.Errorf("configmap %s/%s patch: %w", ns, name, err)
+	}
+	if UseKubectl {
+		return runCommand(Kubectl(ctx, "patch", "configmap", name, "-n", ns, "--type", "merge", "-p", string(patch)))
+	}
+	c, err := clusterClient()
+	if err != nil {
+		return err
+	}
+	if _, err := c.kube.CoreV1().ConfigMaps(ns).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: fieldManager}); err != nil {
+		return fmt.Errorf("configmap %s/%s patch: %w", ns, name, err)
 	}
-	return err
+	return nil
 }
 
-// waitForCRDsEstablished waits for all CRDs to be established.
-func waitForCRDsEstablished() error {
-	return runCommand(exec.Command("kubectl", "wait", "--for=condition=Established", "--all", "crd"))
+// waitForCRDsEstablished waits for all CRDs to be established, for up to 30 seconds or
+// until the deadline of ctx.
+func waitForCRDsEstablished(ctx context.Context) error {
+	return waitForCondition(ctx, crdsResource, "", "", "Established", 30*time.Second)
 }
 
-// waitForPodsReady waits for all pods in the given namespace to be ready.
-func waitForPodsReady(ns string) error {
-	return runCommand(exec.Command("kubectl", "wait", "pod", "--timeout=10m", "--for=condition=Ready", "-l", "!job-name", "-n", ns))
+// waitForDeploymentsAvailable watches all Deployments in the given namespace until they
+// are available, for up to 10 minutes or until the deadline of ctx. When they do not
+// become available, it prints a diagnosis of the namespace.
+func waitForDeploymentsAvailable(ctx context.Context, ns string) error {
+	if err := waitForCondition(ctx, deploymentsResource, ns, "", "Available", 10*time.Minute); err != nil {
+		diagnose(ctx, ns)
+		return err
+	}
+	return nil
+}
+
+// checkTimeout limits the checks of CheckCRDs and CheckDeployments, which are quick
+// even when the installation has more time left
+const checkTimeout = 30 * time.Second
+
+// CheckCRDs reports an error if not all CRDs are established
+func CheckCRDs(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	return waitForCondition(ctx, crdsResource, "", "", "Established", checkTimeout)
+}
+
+// CheckDeployments reports an error if not all Deployments in the given namespaces
+// are available
+func CheckDeployments(ctx context.Context, namespaces ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	for _, ns := range namespaces {
+		if err := waitForCondition(ctx, deploymentsResource, ns, "", "Available", checkTimeout); err != nil {
+			return fmt.Errorf("%s: %w", ns, err)
+		}
+	}
+	return nil
 }
 
 // waitForWebhookReady waits for the Knative Serving webhook to be ready.
-func waitForWebhookReady() error {
+func waitForWebhookReady(ctx context.Context) error {
+	if DryRun != nil {
+		return nil
+	}
 	fmt.Println("    Waiting for webhook to be ready...")
 
-	// Retry for up to 2 minutes (12 attempts with 10s intervals)
-	for range 12 {
-		// Check if the webhook service has ready endpointslices
+	// Wait for up to 2 minutes, or until the deadline of ctx
+	ctx, cancel := context.WithTimeout(ctx, WaitTimeout(ctx, 2*time.Minute))
+	defer cancel()
+	err := poll(ctx, func(ctx context.Context) bool {
+		if webhookReady(ctx) {
+			return true
+		}
+		fmt.Println("    Webhook not ready yet, waiting...")
+		return false
+	})
+	if err != nil {
+		return fmt.Errorf("timeout waiting for webhook to be ready: %w", err)
+	}
+	fmt.Println("    Webhook is ready...")
+	return nil
+}
+
+// webhookReady reports whether the Knative Serving webhook service has a ready endpoint
+func webhookReady(ctx context.Context) bool {
+	if UseKubectl {
 		// NOTE: We use 'kubectl get' instead of 'kubectl wait' because kubectl wait's JSONPath
 		// doesn't support checking if ANY endpoint is ready (wildcard [*] fails with multiple endpoints)
-		checkEndpointSlices := exec.Command("kubectl", "get", "endpointslice",
+		checkEndpointSlices := Kubectl(ctx, "get", "endpointslice",
 			"-l", "kubernetes.io/service-name=webhook",
 			"-n", "knative-serving",
 			"-o", "jsonpath={.items[*].endpoints[?(@.conditions.ready==true)]}")
-
 		output, err := checkEndpointSlices.CombinedOutput()
-		if err == nil && strings.TrimSpace(string(output)) != "" {
-			fmt.Println("    Webhook is ready...")
-			return nil
+		return err == nil && strings.TrimSpace(string(output)) != ""
+	}
+	c, err := clusterClient()
+	if err != nil {
+		return false
+	}
+	list, err := c.kube.DiscoveryV1().EndpointSlices("knative-serving").List(ctx, metav1.ListOptions{LabelSelector: "kubernetes.io/service-name=webhook"})
+	if err != nil {
+		return false
+	}
+	for _, slice := range list.Items {
+		for _, endpoint := range slice.Endpoints {
+			if ptr.Deref(endpoint.Conditions.Ready, false) {
+				return true
+			}
 		}
-
-		fmt.Println("    Webhook not ready yet, waiting...")
-		time.Sleep(10 * time.Second)
 	}
-
-	return fmt.Errorf("timeout waiting for webhook to be ready")
+	return false
 }
@@ -0,0 +1,181 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"context"
+	"fmt"
+)
+
+// EventingKafkaVersion is generated at buildtime via the hack/build.sh script
+var EventingKafkaVersion string
+
+// KafkaImage is the image of the single-node Kafka cluster backing the Kafka broker
+var KafkaImage = "docker.io/apache/kafka:3.9.0"
+
+const (
+	kafkaNamespace        = "kafka"
+	kafkaBootstrapServers = "kafka.kafka.svc.cluster.local:9092"
+)
+
+// kafkaResources returns a single-node Kafka cluster running in KRaft mode, combining
+// the broker and controller roles. Its data is not persisted. Service links are
+// disabled, as the image reads the KAFKA_PORT variable they set as configuration.
+func kafkaResources() string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: %[1]s
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: kafka
+  namespace: %[1]s
+spec:
+  selector:
+    app: kafka
+  ports:
+    - name: broker
+      port: 9092
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: kafka
+  namespace: %[1]s
+spec:
+  replicas: 1
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      app: kafka
+  template:
+    metadata:
+      labels:
+        app: kafka
+    spec:
+      enableServiceLinks: false
+      containers:
+        - name: kafka
+          image: %[2]s
+          ports:
+            - containerPort: 9092
+            - containerPort: 9093
+          env:
+            - name: KAFKA_NODE_ID
+              value: "1"
+            - name: KAFKA_PROCESS_ROLES
+              value: broker,controller
+            - name: KAFKA_LISTENERS
+              value: PLAINTEXT://:9092,CONTROLLER://:9093
+            - name: KAFKA_ADVERTISED_LISTENERS
+              value: PLAINTEXT://%[3]s
+            - name: KAFKA_CONTROLLER_LISTENER_NAMES
+              value: CONTROLLER
+            - name: KAFKA_LISTENER_SECURITY_PROTOCOL_MAP
+              value: CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT
+            - name: KAFKA_CONTROLLER_QUORUM_VOTERS
+              value: 1@localhost:9093
+            - name: KAFKA_OFFSETS_TOPIC_REPLICATION_FACTOR
+              value: "1"
+            - name: KAFKA_TRANSACTION_STATE_LOG_REPLICATION_FACTOR
+              value: "1"
+            - name: KAFKA_TRANSACTION_STATE_LOG_MIN_ISR
+              value: "1"
+            - name: KAFKA_GROUP_INITIAL_REBALANCE_DELAY_MS
+              value: "0"
+          readinessProbe:
+            tcpSocket:
+              port: 9092
+            periodSeconds: 5
+`, kafkaNamespace, KafkaImage, kafkaBootstrapServers)
+}
+
+// kafkaBroker is the Knative Kafka broker, backed by a single-node Kafka cluster
+type kafkaBroker struct{}
+
+func (b *kafkaBroker) Name() string {
+	return "kafka"
+}
+
+func (b *kafkaBroker) Class() string {
+	return "Kafka"
+}
+
+func (b *kafkaBroker) Manifests() []Manifest {
+	return []Manifest{
+		manifest("knative-extensions/eventing-kafka-broker", EventingKafkaVersion, "eventing-kafka-controller.yaml"),
+		manifest("knative-extensions/eventing-kafka-broker", EventingKafkaVersion, "eventing-kafka-broker.yaml"),
+	}
+}
+
+func (b *kafkaBroker) Images() []string {
+	return []string{KafkaImage}
+}
+
+func (b *kafkaBroker) Install(ctx context.Context) error {
+	manifests := b.Manifests()
+
+	if err := retryingApplyConfig(ctx, "kafka", kafkaResources()); err != nil {
+		return fmt.Errorf("kafka: %w", err)
+	}
+	if err := waitForDeploymentsAvailable(ctx, kafkaNamespace); err != nil {
+		return fmt.Errorf("kafka: %w", err)
+	}
+	fmt.Println("    Kafka cluster installed...")
+
+	if err := applyManifest(ctx, manifests[0]); err != nil {
+		return fmt.Errorf("wait: %w", err)
+	}
+	if err := waitForCRDsEstablished(ctx); err != nil {
+		return fmt.Errorf("crds: %w", err)
+	}
+	if err := waitForDeploymentsAvailable(ctx, "knative-eventing"); err != nil {
+		return fmt.Errorf("kafka controller: %w", err)
+	}
+	fmt.Println("    Kafka controller v" + EventingKafkaVersion + " installed...")
+
+	if err := applyManifest(ctx, manifests[1]); err != nil {
+		return fmt.Errorf("wait: %w", err)
+	}
+	if err := waitForDeploymentsAvailable(ctx, "knative-eventing"); err != nil {
+		return fmt.Errorf("kafka broker: %w", err)
+	}
+	fmt.Println("    Kafka broker installed...")
+
+	if err := PatchConfigMap(ctx, "knative-eventing", "kafka-broker-config", map[string]string{
+		"bootstrap.servers":                kafkaBootstrapServers,
+		"default.topic.partitions":         "1",
+		"default.topic.replication.factor": "1",
+	}); err != nil {
+		return fmt.Errorf("kafka broker config: %w", err)
+	}
+	if err := PatchConfigMap(ctx, "knative-eventing", "config-br-defaults", map[string]string{
+		"default-br-config": `clusterDefault:
+  brokerClass: Kafka
+  apiVersion: v1
+  kind: ConfigMap
+  name: kafka-broker-config
+  namespace: knative-eventing
+`,
+	}); err != nil {
+		return fmt.Errorf("broker defaults: %w", err)
+	}
+	fmt.Println("    Kafka set as the default broker class...")
+
+	return nil
+}
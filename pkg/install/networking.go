@@ -0,0 +1,303 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Networking is a networking layer for Knative Serving
+type Networking interface {
+	// Name is the name selecting the layer, e.g. "kourier"
+	Name() string
+	// Manifests returns the release manifests of the layer
+	Manifests() []Manifest
+	// Images returns the images the layer runs that are not referenced by the release
+	// manifests
+	Images() []string
+	// Install installs the layer, waits for it to be ready and makes it the ingress
+	// class of Serving
+	Install(ctx context.Context) error
+	// Namespaces returns the namespaces of the layer's deployments
+	Namespaces() []string
+	// ExposeNodePort exposes the layer's gateway on NodePort 31080, and sets up the
+	// sslip.io domain for the given node IP
+	ExposeNodePort(ctx context.Context, nodeIP string) error
+	// ExposeLoadBalancer sets up the sslip.io domain for the IP of the layer's
+	// LoadBalancer gateway service
+	ExposeLoadBalancer(ctx context.Context) error
+	// LoadBalancerAddress waits up to the given timeout for the layer's gateway
+	// service to be assigned an address, and returns its IP or hostname. It returns ""
+	// if no address was assigned.
+	LoadBalancerAddress(ctx context.Context, timeout time.Duration) string
+}
+
+// Ingress is the networking layer installed with Serving
+var Ingress Networking = networkingLayers["kourier"]
+
+// SetIngress selects the networking layer installed with Serving. An empty name keeps
+// the default, Kourier.
+func SetIngress(name string) error {
+	if name == "" {
+		return nil
+	}
+	n, ok := networkingLayers[name]
+	if !ok {
+		return fmt.Errorf("unsupported ingress %q, must be one of: %s", name, strings.Join(IngressNames(), ", "))
+	}
+	Ingress = n
+	return nil
+}
+
+// IngressNames returns the names of the supported networking layers
+func IngressNames() []string {
+	names := make([]string, 0, len(networkingLayers))
+	for name := range networkingLayers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var networkingLayers = map[string]Networking{
+	"kourier": &networkingLayer{
+		name:         "kourier",
+		title:        "Kourier",
+		repo:         "knative-extensions/net-kourier",
+		version:      func() string { return KourierVersion },
+		files:        []string{"kourier.yaml"},
+		namespaces:   []string{"kourier-system", "knative-serving"},
+		ingressClass: "kourier.ingress.networking.knative.dev",
+		gateway: gateway{
+			namespace: "kourier-system",
+			service:   "kourier",
+			selector:  map[string]string{"app": "3scale-kourier-gateway"},
+		},
+	},
+	"contour": &networkingLayer{
+		name:         "contour",
+		title:        "Contour",
+		repo:         "knative-extensions/net-contour",
+		version:      func() string { return ContourVersion },
+		files:        []string{"contour.yaml", "net-contour.yaml"},
+		namespaces:   []string{"contour-external", "contour-internal", "knative-serving"},
+		ingressClass: "contour.ingress.networking.knative.dev",
+		gateway: gateway{
+			namespace: "contour-external",
+			service:   "envoy",
+			selector:  map[string]string{"app": "envoy"},
+		},
+	},
+	"istio": &networkingLayer{
+		name:         "istio",
+		title:        "Istio",
+		repo:         "knative-extensions/net-istio",
+		version:      func() string { return IstioVersion },
+		files:        []string{"istio.yaml", "net-istio.yaml"},
+		namespaces:   []string{"istio-system", "knative-serving"},
+		ingressClass: "istio.ingress.networking.knative.dev",
+		gateway: gateway{
+			namespace: "istio-system",
+			service:   "istio-ingressgateway",
+			selector:  map[string]string{"istio": "ingressgateway"},
+		},
+	},
+	"gateway-api": &gatewayAPI{},
+}
+
+// gateway is the service receiving the external traffic of a networking layer
+type gateway struct {
+	namespace string
+	service   string
+	selector  map[string]string
+}
+
+// networkingLayer is a Networking installed from the release manifests of a
+// knative-extensions net-* repository
+type networkingLayer struct {
+	name         string
+	title        string
+	repo         string
+	version      func() string
+	files        []string
+	namespaces   []string
+	ingressClass string
+	gateway      gateway
+}
+
+func (n *networkingLayer) Name() string {
+	return n.name
+}
+
+func (n *networkingLayer) Manifests() []Manifest {
+	manifests := make([]Manifest, 0, len(n.files))
+	for _, file := range n.files {
+		manifests = append(manifests, manifest(n.repo, n.version(), file))
+	}
+	return manifests
+}
+
+func (n *networkingLayer) Images() []string {
+	return nil
+}
+
+func (n *networkingLayer) Namespaces() []string {
+	return n.namespaces
+}
+
+func (n *networkingLayer) Install(ctx context.Context) error {
+	fmt.Println("🕸️ Installing " + n.title + " networking layer v" + n.version() + " ...")
+
+	for _, m := range n.Manifests() {
+		if err := applyManifest(ctx, m); err != nil {
+			return fmt.Errorf("wait: %w", err)
+		}
+	}
+	for _, ns := range n.namespaces {
+		if err := waitForDeploymentsAvailable(ctx, ns); err != nil {
+			return fmt.Errorf("%s: %w", ns, err)
+		}
+	}
+	fmt.Println("    " + n.title + " installed...")
+
+	if err := PatchConfigMap(ctx, "knative-serving", "config-network", map[string]string{"ingress.class": n.ingressClass}); err != nil {
+		return fmt.Errorf("ingress error: %w", err)
+	}
+	fmt.Println("    Ingress patched...")
+
+	fmt.Println("    Finished installing " + n.title + " Networking layer")
+
+	return nil
+}
+
+func (n *networkingLayer) ExposeNodePort(ctx context.Context, nodeIP string) error {
+	return exposeNodePort(ctx, n.name, n.title, n.gateway, 8080, nodeIP)
+}
+
+func (n *networkingLayer) ExposeLoadBalancer(ctx context.Context) error {
+	fmt.Println("🕸️ Configuring " + n.title + " LoadBalancer...")
+
+	if err := applyManifest(ctx, manifest("knative/serving", ServingVersion, "serving-default-domain.yaml")); err != nil {
+		return fmt.Errorf("default domain: %w", err)
+	}
+	if err := waitForDeploymentsAvailable(ctx, "knative-serving"); err != nil {
+		return fmt.Errorf("core: %w", err)
+	}
+
+	fmt.Println("    Domain DNS set up...")
+
+	fmt.Println("    Finished configuring " + n.title)
+	return nil
+}
+
+func (n *networkingLayer) LoadBalancerAddress(ctx context.Context, timeout time.Duration) string {
+	return loadBalancerAddress(ctx, n.gateway, timeout)
+}
+
+// exposeNodePort exposes the gateway pods on NodePort 31080, and sets up the sslip.io
+// domain for the given node IP
+func exposeNodePort(ctx context.Context, name, title string, gw gateway, targetPort int, nodeIP string) error {
+	fmt.Println("🕸️ Configuring " + title + " NodePort...")
+
+	selector := make([]string, 0, len(gw.selector))
+	for k, v := range gw.selector {
+		selector = append(selector, fmt.Sprintf("    %s: %s", k, v))
+	}
+	sort.Strings(selector)
+
+	config := fmt.Sprintf(`apiVersion: v1
+kind: Service
+metadata:
+  name: %s-ingress
+  namespace: %s
+  labels:
+    networking.knative.dev/ingress-provider: %s
+spec:
+  type: NodePort
+  selector:
+%s
+  ports:
+    - name: http2
+      nodePort: 31080
+      port: 80
+      targetPort: %d`, name, gw.namespace, name, strings.Join(selector, "\n"), targetPort)
+
+	if err := applyConfig(ctx, name+"-ingress", config); err != nil {
+		return fmt.Errorf("%s service: %w", name, err)
+	}
+
+	fmt.Println("    " + title + " service installed...")
+
+	if err := ConfigureDomain(ctx, nodeIP+".sslip.io"); err != nil {
+		return err
+	}
+	fmt.Println("    Finished configuring " + title)
+
+	return nil
+}
+
+// loadBalancerAddress waits up to the given timeout for the gateway service to be
+// assigned an address, and returns its IP or hostname, or "" if none was assigned
+func loadBalancerAddress(ctx context.Context, gw gateway, timeout time.Duration) string {
+	if DryRun != nil {
+		return "<" + gw.service + "-address>"
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var address string
+	_ = poll(ctx, func(ctx context.Context) bool {
+		address = serviceAddress(ctx, gw)
+		return address != ""
+	})
+	return address
+}
+
+// serviceAddress returns the IP or hostname assigned to the gateway service, if any
+func serviceAddress(ctx context.Context, gw gateway) string {
+	if UseKubectl {
+		getAddress := Kubectl(ctx, "get", "service", gw.service, "-n", gw.namespace,
+			"-o", "jsonpath={.status.loadBalancer.ingress[0].ip}{.status.loadBalancer.ingress[0].hostname}")
+		out, err := getAddress.Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	}
+	c, err := clusterClient()
+	if err != nil {
+		return ""
+	}
+	svc, err := c.kube.CoreV1().Services(gw.namespace).Get(ctx, gw.service, metav1.GetOptions{})
+	if err != nil || len(svc.Status.LoadBalancer.Ingress) == 0 {
+		return ""
+	}
+	ingress := svc.Status.LoadBalancer.Ingress[0]
+	return ingress.IP + ingress.Hostname
+}
+
+// ConfigureDomain sets the domain used for Knative Services
+func ConfigureDomain(ctx context.Context, domain string) error {
+	if err := PatchConfigMap(ctx, "knative-serving", "config-domain", map[string]string{domain: ""}); err != nil {
+		return fmt.Errorf("domain dns: %w", err)
+	}
+	fmt.Println("    Domain DNS set up...")
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSetIngress(t *testing.T) {
+	t.Cleanup(func() { Ingress = networkingLayers["kourier"] })
+
+	assert.Equal(t, Ingress.Name(), "kourier")
+	assert.NilError(t, SetIngress(""))
+	assert.Equal(t, Ingress.Name(), "kourier")
+
+	ContourVersion = "1.17.0"
+	t.Cleanup(func() { ContourVersion = "" })
+	assert.NilError(t, SetIngress("contour"))
+	assert.DeepEqual(t, Ingress.Manifests(), []Manifest{
+		{Repo: "knative-extensions/net-contour", Version: "1.17.0", File: "contour.yaml"},
+		{Repo: "knative-extensions/net-contour", Version: "1.17.0", File: "net-contour.yaml"},
+	})
+
+	assert.ErrorContains(t, SetIngress("nginx"), `unsupported ingress "nginx", must be one of: contour, gateway-api, istio, kourier`)
+}
+
+func TestGatewayAPIManifests(t *testing.T) {
+	NetGatewayAPIVersion = "1.17.0"
+	t.Cleanup(func() { NetGatewayAPIVersion = "" })
+
+	var paths []string
+	for _, m := range networkingLayers["gateway-api"].Manifests() {
+		paths = append(paths, m.Path())
+	}
+	assert.DeepEqual(t, paths, []string{
+		"kubernetes-sigs/gateway-api/releases/download/v" + GatewayAPIVersion + "/standard-install.yaml",
+		"envoyproxy/gateway/releases/download/v" + EnvoyGatewayVersion + "/install.yaml",
+		"knative-extensions/net-gateway-api/releases/download/knative-v1.17.0/net-gateway-api.yaml",
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EventingRabbitMQVersion is generated at buildtime via the hack/build.sh script
+var EventingRabbitMQVersion string
+
+// NOTE: the operator and cert-manager versions must be supported by
+// EventingRabbitMQVersion.
+var (
+	CertManagerVersion              = "1.16.3"
+	RabbitMQClusterOperatorVersion  = "2.12.0"
+	RabbitMQTopologyOperatorVersion = "1.15.0"
+	RabbitMQImage                   = "docker.io/library/rabbitmq:3.13-management"
+)
+
+const (
+	rabbitMQNamespace = "rabbitmq"
+	rabbitMQCluster   = "rabbitmq"
+	rabbitMQConfig    = "rabbitmq-broker-config"
+)
+
+// rabbitMQResources returns a single-replica RabbitmqCluster. The topology operator
+// only manages the broker's exchanges and queues in other namespaces when the cluster
+// allows them.
+func rabbitMQResources() string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: %[1]s
+---
+apiVersion: rabbitmq.com/v1beta1
+kind: RabbitmqCluster
+metadata:
+  name: %[2]s
+  namespace: %[1]s
+  annotations:
+    rabbitmq.com/topology-allowed-namespaces: "*"
+spec:
+  replicas: 1
+  image: %[3]s
+`, rabbitMQNamespace, rabbitMQCluster, RabbitMQImage)
+}
+
+// rabbitMQBrokerConfig returns the RabbitmqBrokerConfig of the default broker class,
+// referencing the RabbitmqCluster
+func rabbitMQBrokerConfig() string {
+	return fmt.Sprintf(`apiVersion: eventing.knative.dev/v1alpha1
+kind: RabbitmqBrokerConfig
+metadata:
+  name: %[3]s
+  namespace: knative-eventing
+spec:
+  rabbitmqClusterReference:
+    name: %[2]s
+    namespace: %[1]s
+  queueType: classic
+`, rabbitMQNamespace, rabbitMQCluster, rabbitMQConfig)
+}
+
+// rabbitMQBroker is the Knative RabbitMQ broker, backed by a single-replica RabbitMQ
+// cluster managed by the RabbitMQ operators
+type rabbitMQBroker struct{}
+
+func (b *rabbitMQBroker) Name() string {
+	return "rabbitmq"
+}
+
+func (b *rabbitMQBroker) Class() string {
+	return "RabbitMQBroker"
+}
+
+func (b *rabbitMQBroker) Manifests() []Manifest {
+	return []Manifest{
+		{Repo: "cert-manager/cert-manager", Version: CertManagerVersion, File: "cert-manager.yaml", Tag: "v" + CertManagerVersion},
+		{Repo: "rabbitmq/cluster-operator", Version: RabbitMQClusterOperatorVersion, File: "cluster-operator.yml", Tag: "v" + RabbitMQClusterOperatorVersion},
+		{Repo: "rabbitmq/messaging-topology-operator", Version: RabbitMQTopologyOperatorVersion, File: "messaging-topology-operator-with-certmanager.yaml", Tag: "v" + RabbitMQTopologyOperatorVersion},
+		manifest("knative-extensions/eventing-rabbitmq", EventingRabbitMQVersion, "rabbitmq-broker.yaml"),
+	}
+}
+
+func (b *rabbitMQBroker) Images() []string {
+	return []string{RabbitMQImage}
+}
+
+func (b *rabbitMQBroker) Install(ctx context.Context) error {
+	manifests := b.Manifests()
+
+	if err := applyManifest(ctx, manifests[0]); err != nil {
+		return fmt.Errorf("wait: %w", err)
+	}
+	if err := waitForCRDsEstablished(ctx); err != nil {
+		return fmt.Errorf("crds: %w", err)
+	}
+	if err := waitForDeploymentsAvailable(ctx, "cert-manager"); err != nil {
+		return fmt.Errorf("cert-manager: %w", err)
+	}
+	fmt.Println("    cert-manager v" + CertManagerVersion + " installed...")
+
+	if err := applyManifest(ctx, manifests[1]); err != nil {
+		return fmt.Errorf("wait: %w", err)
+	}
+	if err := applyManifest(ctx, manifests[2]); err != nil {
+		return fmt.Errorf("wait: %w", err)
+	}
+	if err := waitForCRDsEstablished(ctx); err != nil {
+		return fmt.Errorf("crds: %w", err)
+	}
+	if err := waitForDeploymentsAvailable(ctx, "rabbitmq-system"); err != nil {
+		return fmt.Errorf("rabbitmq operators: %w", err)
+	}
+	fmt.Println("    RabbitMQ operators installed...")
+
+	if err := retryingApplyConfig(ctx, "rabbitmq", rabbitMQResources()); err != nil {
+		return fmt.Errorf("rabbitmq: %w", err)
+	}
+	if err := waitForCondition(ctx, rabbitMQClustersResource, rabbitMQNamespace, rabbitMQCluster, "AllReplicasReady", 10*time.Minute); err != nil {
+		diagnose(ctx, rabbitMQNamespace)
+		return fmt.Errorf("rabbitmq: %w", err)
+	}
+	fmt.Println("    RabbitMQ cluster installed...")
+
+	if err := applyManifest(ctx, manifests[3]); err != nil {
+		return fmt.Errorf("wait: %w", err)
+	}
+	if err := waitForCRDsEstablished(ctx); err != nil {
+		return fmt.Errorf("crds: %w", err)
+	}
+	if err := waitForDeploymentsAvailable(ctx, "knative-eventing"); err != nil {
+		return fmt.Errorf("rabbitmq broker: %w", err)
+	}
+	fmt.Println("    RabbitMQ broker v" + EventingRabbitMQVersion + " installed...")
+
+	if err := retryingApplyConfig(ctx, rabbitMQConfig, rabbitMQBrokerConfig()); err != nil {
+		return fmt.Errorf("rabbitmq broker config: %w", err)
+	}
+	if err := PatchConfigMap(ctx, "knative-eventing", "config-br-defaults", map[string]string{
+		"default-br-config": fmt.Sprintf(`clusterDefault:
+  brokerClass: RabbitMQBroker
+  apiVersion: eventing.knative.dev/v1alpha1
+  kind: RabbitmqBrokerConfig
+  name: %s
+  namespace: knative-eventing
+`, rabbitMQConfig),
+	}); err != nil {
+		return fmt.Errorf("broker defaults: %w", err)
+	}
+	fmt.Println("    RabbitMQ set as the default broker class...")
+
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"context"
+	"strings"
+)
+
+// Recorder receives the changes quickstart makes, in the order they are made
+type Recorder interface {
+	// Manifest records that a release manifest is applied
+	Manifest(ctx context.Context, m Manifest) error
+	// Resources records that inline resources are applied
+	Resources(title, config string) error
+	// ConfigMapPatch records that data is merged into a ConfigMap
+	ConfigMapPatch(ns, name string, data map[string]string) error
+	// Step records a change made outside of the cluster, e.g. the configuration the
+	// cluster is created with
+	Step(title, content string) error
+}
+
+// DryRun records the changes instead of making them when set. Commands querying or
+// waiting for the cluster do nothing, and addresses are reported as placeholders.
+var DryRun Recorder
+
+// applyConfig server-side applies the given resources, or records them on dry runs
+func applyConfig(ctx context.Context, title, config string) error {
+	if DryRun != nil {
+		return DryRun.Resources(title, config)
+	}
+	if UseKubectl {
+		apply := Kubectl(ctx, applyArgs("-")...)
+		apply.Stdin = strings.NewReader(config)
+		return runCommand(apply)
+	}
+	return apply(ctx, config)
+}
@@ -0,0 +1,83 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// RetryBackoff is the backoff between the tries of applies and downloads that fail
+// transiently, e.g. while the webhooks of new CRDs start. Steps is the number of tries.
+var RetryBackoff = wait.Backoff{Duration: 2 * time.Second, Factor: 2, Jitter: 0.2, Steps: 5, Cap: 30 * time.Second}
+
+// PollBackoff is the backoff between the checks while waiting for a condition, which
+// go on until the deadline of the wait
+var PollBackoff = wait.Backoff{Duration: time.Second, Factor: 2, Jitter: 0.2, Steps: 10, Cap: 10 * time.Second}
+
+// WaitTimeout returns the time left until the deadline of ctx, or def if ctx has none.
+// Waits that take a timeout instead of ctx, like kubectl wait, use it to end with the
+// installation's timeout budget.
+func WaitTimeout(ctx context.Context, def time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return def
+	}
+	return max(time.Until(deadline).Truncate(time.Second), time.Second)
+}
+
+// retry calls fn until it succeeds, at most RetryBackoff.Steps times, backing off
+// between the tries. It returns the last error of fn, along with the error of ctx if
+// ctx is done before the tries are.
+func retry(ctx context.Context, fn func() error) error {
+	b := RetryBackoff
+	tries := b.Steps
+	for i := 1; ; i++ {
+		err := fn()
+		if err == nil || i >= tries {
+			return err
+		}
+		if ctxErr := sleep(ctx, b.Step()); ctxErr != nil {
+			return fmt.Errorf("%w: %w", ctxErr, err)
+		}
+	}
+}
+
+// poll calls check until it reports true, backing off between the checks per
+// PollBackoff, or returns the error of ctx once it is done
+func poll(ctx context.Context, check func(context.Context) bool) error {
+	b := PollBackoff
+	for !check(ctx) {
+		if err := sleep(ctx, b.Step()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sleep pauses for the given duration, or returns the error of ctx once it is done
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWaitTimeout(t *testing.T) {
+	assert.Equal(t, WaitTimeout(context.Background(), 10*time.Minute), 10*time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	timeout := WaitTimeout(ctx, 10*time.Minute)
+	assert.Assert(t, timeout > 59*time.Minute && timeout <= time.Hour, timeout)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	assert.Equal(t, WaitTimeout(ctx, 10*time.Minute), time.Second)
+}
+
+func TestRetry(t *testing.T) {
+	backoff := RetryBackoff
+	t.Cleanup(func() { RetryBackoff = backoff })
+	RetryBackoff.Duration = time.Millisecond
+
+	tries := 0
+	err := retry(context.Background(), func() error {
+		tries++
+		if tries < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, tries, 3)
+
+	tries = 0
+	err = retry(context.Background(), func() error {
+		tries++
+		return errors.New("failed")
+	})
+	assert.Error(t, err, "failed")
+	assert.Equal(t, tries, RetryBackoff.Steps)
+
+	// Backing off ends with ctx
+	RetryBackoff.Duration = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = retry(ctx, func() error { return errors.New("failed") })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "failed")
+}
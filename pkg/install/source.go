@@ -0,0 +1,211 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"knative.dev/kn-plugin-quickstart/pkg/cache"
+)
+
+// DefaultManifestSource is where release manifests are downloaded from by default
+const DefaultManifestSource = "https://github.com"
+
+// ManifestSource is the base URL or local directory release manifests are read from.
+// It has the same layout as the GitHub release assets, e.g.
+// <source>/knative/serving/releases/download/knative-v1.17.0/serving-core.yaml
+var ManifestSource = DefaultManifestSource
+
+// SetManifestSource sets ManifestSource from an HTTP(S) base URL, a file:// URL or a
+// local directory. An empty source keeps the default.
+func SetManifestSource(source string) error {
+	switch {
+	case source == "":
+		return nil
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		ManifestSource = strings.TrimSuffix(source, "/")
+		return nil
+	}
+
+	dir, err := filepath.Abs(strings.TrimPrefix(source, "file://"))
+	if err != nil {
+		return fmt.Errorf("invalid manifest source %q: %w", source, err)
+	}
+	if fi, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("invalid manifest source: %w", err)
+	} else if !fi.IsDir() {
+		return fmt.Errorf("invalid manifest source: %s is not a directory", dir)
+	}
+	ManifestSource = dir
+	return nil
+}
+
+// ManifestCache caches manifests downloaded from HTTP sources. Manifests are not cached
+// when it is nil.
+var ManifestCache = cache.Default()
+
+// Manifest is a release asset of a GitHub repository
+type Manifest struct {
+	Repo    string
+	Version string
+	File    string
+	// Tag is the release tag, knative-v<Version> when empty
+	Tag string
+}
+
+func manifest(repo, version, file string) Manifest {
+	return Manifest{Repo: repo, Version: version, File: file}
+}
+
+// Path returns the slash-separated path of the manifest relative to a manifest source
+func (m Manifest) Path() string {
+	tag := m.Tag
+	if tag == "" {
+		tag = "knative-v" + m.Version
+	}
+	return m.Repo + "/releases/download/" + tag + "/" + m.File
+}
+
+// Location returns the URL or path of the manifest in ManifestSource
+func (m Manifest) Location() string {
+	if RemoteManifestSource() {
+		return ManifestSource + "/" + m.Path()
+	}
+	return filepath.Join(ManifestSource, filepath.FromSlash(m.Path()))
+}
+
+// Fetch returns the path or URL kubectl applies the manifest from. Manifests from HTTP
+// sources are downloaded into ManifestCache, and reused from there on later runs.
+// Downloads that do not match the cached digest are not retried.
+func (m Manifest) Fetch(ctx context.Context) (string, error) {
+	if !RemoteManifestSource() || ManifestCache == nil {
+		return m.Location(), nil
+	}
+	var path string
+	var mismatch error
+	err := retry(ctx, func() error {
+		var err error
+		path, err = ManifestCache.Fetch(ctx, m.Location(), m.Repo, m.Version, m.File)
+		if errors.Is(err, cache.ErrDigestMismatch) {
+			mismatch = err
+			return nil
+		}
+		return err
+	})
+	if mismatch != nil {
+		return "", mismatch
+	}
+	return path, err
+}
+
+// Read returns the content of the manifest, fetching it like Fetch
+func (m Manifest) Read(ctx context.Context) (string, error) {
+	location, err := m.Fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", m.Path(), err)
+	}
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", m.Path(), err)
+		}
+		return string(data), nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", location, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", location, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", location, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", location, err)
+	}
+	return string(data), nil
+}
+
+// Manifests returns the release manifests of the current component versions,
+// networking layer and broker class
+func Manifests() []Manifest {
+	manifests := []Manifest{
+		manifest("knative/serving", ServingVersion, "serving-crds.yaml"),
+		manifest("knative/serving", ServingVersion, "serving-core.yaml"),
+		manifest("knative/serving", ServingVersion, "serving-default-domain.yaml"),
+	}
+	manifests = append(manifests, Ingress.Manifests()...)
+	manifests = append(manifests,
+		manifest("knative/eventing", EventingVersion, "eventing-crds.yaml"),
+		manifest("knative/eventing", EventingVersion, "eventing-core.yaml"),
+	)
+	return append(manifests, Broker.Manifests()...)
+}
+
+// AllManifests returns the manifests of Serving, Eventing and every supported
+// networking layer and broker class, whatever Ingress and Broker are
+func AllManifests() []Manifest {
+	manifests := []Manifest{
+		manifest("knative/serving", ServingVersion, "serving-crds.yaml"),
+		manifest("knative/serving", ServingVersion, "serving-core.yaml"),
+		manifest("knative/serving", ServingVersion, "serving-default-domain.yaml"),
+		manifest("knative/eventing", EventingVersion, "eventing-crds.yaml"),
+		manifest("knative/eventing", EventingVersion, "eventing-core.yaml"),
+	}
+	for _, name := range IngressNames() {
+		manifests = append(manifests, networkingLayers[name].Manifests()...)
+	}
+	for _, name := range BrokerClassNames() {
+		manifests = append(manifests, brokerClasses[name].Manifests()...)
+	}
+	return manifests
+}
+
+// RemoteManifestSource reports whether manifests are downloaded from an HTTP source
+func RemoteManifestSource() bool {
+	return strings.HasPrefix(ManifestSource, "http://") || strings.HasPrefix(ManifestSource, "https://")
+}
+
+// applyManifest fetches the manifest and applies it, or records it on dry runs
+func applyManifest(ctx context.Context, m Manifest) error {
+	if DryRun != nil {
+		return DryRun.Manifest(ctx, m)
+	}
+	if UseKubectl {
+		path, err := m.Fetch(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch: %w", err)
+		}
+		return retryingApply(ctx, path)
+	}
+	data, err := m.Read(ctx)
+	if err != nil {
+		return err
+	}
+	return retry(ctx, func() error {
+		return apply(ctx, data)
+	})
+}
@@ -0,0 +1,59 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestManifestSource(t *testing.T) {
+	t.Cleanup(func() { ManifestSource = DefaultManifestSource })
+
+	assert.Equal(t, manifest("knative/serving", "1.17.0", "serving-core.yaml").Location(),
+		"https://github.com/knative/serving/releases/download/knative-v1.17.0/serving-core.yaml")
+
+	assert.NilError(t, SetManifestSource("https://mirror.example.com/github/"))
+	assert.Equal(t, manifest("knative/eventing", "1.17.0", "eventing-crds.yaml").Location(),
+		"https://mirror.example.com/github/knative/eventing/releases/download/knative-v1.17.0/eventing-crds.yaml")
+
+	dir := t.TempDir()
+	assert.NilError(t, SetManifestSource("file://"+dir))
+	assert.Equal(t, manifest("knative-extensions/net-kourier", "1.17.0", "kourier.yaml").Location(),
+		filepath.Join(dir, "knative-extensions/net-kourier/releases/download/knative-v1.17.0/kourier.yaml"))
+
+	assert.ErrorContains(t, SetManifestSource(filepath.Join(dir, "missing")), "invalid manifest source")
+}
+
+func TestAllManifests(t *testing.T) {
+	repos := map[string]bool{}
+	for _, m := range AllManifests() {
+		repos[m.Repo] = true
+	}
+	for _, repo := range []string{
+		"knative/serving",
+		"knative/eventing",
+		"knative-extensions/net-kourier",
+		"knative-extensions/net-contour",
+		"knative-extensions/net-istio",
+		"knative-extensions/net-gateway-api",
+		"knative-extensions/eventing-kafka-broker",
+		"knative-extensions/eventing-rabbitmq",
+	} {
+		assert.Assert(t, repos[repo], repo)
+	}
+}
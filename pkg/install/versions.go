@@ -0,0 +1,83 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var versionRegexp = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)$`)
+
+// OverrideVersions replaces the build-time component versions with the given ones.
+// Empty versions keep the build-time default. It fails on malformed versions, and
+// warns when the resulting versions are not from the same Knative release.
+func OverrideVersions(serving, kourier, eventing string) error {
+	overrides := []struct {
+		component string
+		version   string
+		target    *string
+	}{
+		{"serving", serving, &ServingVersion},
+		{"kourier", kourier, &KourierVersion},
+		{"eventing", eventing, &EventingVersion},
+	}
+	for _, o := range overrides {
+		if o.version == "" {
+			continue
+		}
+		v, err := normalizeVersion(o.version)
+		if err != nil {
+			return fmt.Errorf("invalid %s version: %w", o.component, err)
+		}
+		*o.target = v
+	}
+
+	if !compatible(ServingVersion, KourierVersion, EventingVersion) {
+		fmt.Printf("WARNING: Serving v%s, Kourier v%s and Eventing v%s are not from the same Knative release, this combination is not known to work\n",
+			ServingVersion, KourierVersion, EventingVersion)
+	}
+	return nil
+}
+
+// normalizeVersion strips the "knative-v" or "v" prefix of a release version, and
+// checks that the rest is a major.minor.patch version
+func normalizeVersion(v string) (string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(v, "knative-"), "v")
+	if !versionRegexp.MatchString(trimmed) {
+		return "", fmt.Errorf("%q is not a version of the form 1.x.y", v)
+	}
+	return trimmed, nil
+}
+
+// compatible reports whether the given versions share the same major and minor version,
+// which is how Knative components of the same release are versioned. Empty versions,
+// e.g. of development builds, are ignored.
+func compatible(versions ...string) bool {
+	release := ""
+	for _, v := range versions {
+		m := versionRegexp.FindStringSubmatch(v)
+		if m == nil {
+			continue
+		}
+		if r := m[1] + "." + m[2]; release == "" {
+			release = r
+		} else if r != release {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestNormalizeVersion(t *testing.T) {
+	for _, v := range []string{"1.17.0", "v1.17.0", "knative-v1.17.0"} {
+		got, err := normalizeVersion(v)
+		assert.NilError(t, err)
+		assert.Equal(t, got, "1.17.0")
+	}
+	for _, v := range []string{"1.17", "latest", "v1.17.0-rc1", ""} {
+		_, err := normalizeVersion(v)
+		assert.ErrorContains(t, err, "is not a version of the form 1.x.y")
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	assert.Assert(t, compatible("1.17.2", "1.17.0", "1.17.1"))
+	assert.Assert(t, compatible("1.17.2", "", "1.17.1"))
+	assert.Assert(t, !compatible("1.17.0", "1.16.0", "1.17.0"))
+}
+
+func TestOverrideVersions(t *testing.T) {
+	ServingVersion, KourierVersion, EventingVersion = "1.17.0", "1.17.0", "1.17.0"
+	t.Cleanup(func() { ServingVersion, KourierVersion, EventingVersion = "", "", "" })
+
+	assert.NilError(t, OverrideVersions("v1.18.1", "", "1.18.0"))
+	assert.Equal(t, ServingVersion, "1.18.1")
+	assert.Equal(t, KourierVersion, "1.17.0")
+	assert.Equal(t, EventingVersion, "1.18.0")
+
+	assert.ErrorContains(t, OverrideVersions("", "main", ""), "invalid kourier version")
+}
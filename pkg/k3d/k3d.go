@@ -0,0 +1,355 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k3d
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	dclient "github.com/docker/docker/client"
+	qerrors "knative.dev/kn-plugin-quickstart/pkg/errors"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+	"knative.dev/kn-plugin-quickstart/pkg/kind"
+	"knative.dev/kn-plugin-quickstart/pkg/prompt"
+	"knative.dev/kn-plugin-quickstart/pkg/quickstart"
+)
+
+// NOTE: If you are changing k3dVersion, please also update the k3d version
+// listed here:
+// https://github.com/knative-extensions/kn-plugin-quickstart/blob/main/README.md
+//
+// kubernetesVersion should follow the kind and minikube defaults.
+var (
+	kubernetesVersion = "rancher/k3s:v1.34.1-k3s1"
+	k3dVersion        = release{5, 7}
+	registryPort      = "5002"
+)
+
+// Options configures the k3d cluster created by quickstart
+type Options struct {
+	Name              string
+	KubernetesVersion string
+	Registry          bool
+	HostPort          int
+}
+
+// Provider is the quickstart.ClusterProvider creating k3d clusters
+type Provider struct {
+	opts     Options
+	prompter prompt.Prompter
+}
+
+var _ quickstart.ClusterProvider = (*Provider)(nil)
+
+// NewProvider returns a Provider for the k3d cluster described by opts
+func NewProvider(opts Options, p prompt.Prompter) *Provider {
+	if opts.KubernetesVersion == "" {
+		opts.KubernetesVersion = kubernetesVersion
+	} else if !strings.Contains(opts.KubernetesVersion, ":") {
+		opts.KubernetesVersion = "rancher/k3s:v" + strings.TrimPrefix(opts.KubernetesVersion, "v") + "-k3s1"
+	}
+	return &Provider{opts: opts, prompter: p}
+}
+
+// Name returns the provider name
+func (k *Provider) Name() string {
+	return "k3d"
+}
+
+// ClusterName returns the name of the k3d cluster
+func (k *Provider) ClusterName() string {
+	return k.opts.Name
+}
+
+// KubeContext returns the kubeconfig context k3d creates for the cluster
+func (k *Provider) KubeContext() string {
+	return "k3d-" + k.opts.Name
+}
+
+// registryName is the name of the k3d-managed registry container of the cluster
+func (k *Provider) registryName() string {
+	return RegistryName(k.opts.Name)
+}
+
+// RegistryName returns the name of the registry container of the given cluster
+func RegistryName(name string) string {
+	return "k3d-" + name + "-registry"
+}
+
+// Preflight checks that k3d is installed and recent enough
+func (k *Provider) Preflight(ctx context.Context) error {
+	fmt.Println("✅ Checking dependencies...")
+	if err := k.checkK3dVersion(ctx); err != nil {
+		return fmt.Errorf("k3d version check: %w", err)
+	}
+	return nil
+}
+
+// Exists reports whether the k3d cluster is present
+func (k *Provider) Exists(ctx context.Context) (bool, error) {
+	names, err := Clusters(ctx)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(names, k.opts.Name), nil
+}
+
+// Create creates a new k3d cluster with Traefik disabled, so that it does not
+// conflict with the networking layer, and the ingress NodePort mapped to the host port
+func (k *Provider) Create(ctx context.Context) error {
+	fmt.Println("☸ Creating k3d cluster...")
+
+	args := []string{"cluster", "create", k.opts.Name,
+		"--image", k.opts.KubernetesVersion,
+		"--port", fmt.Sprintf("%d:31080@server:0", k.opts.HostPort),
+		"--k3s-arg", "--disable=traefik@server:0",
+		"--wait", "--timeout", install.WaitTimeout(ctx, 120*time.Second).String(),
+	}
+
+	var mirrors string
+	if k.opts.Registry {
+		// Let pods pull from localhost:<port>, the same name used to push from
+		// the host, as it is done for the kind registry
+		mirrors = fmt.Sprintf(`mirrors:
+  "localhost:%s":
+    endpoint:
+      - http://%s:5000
+`, registryPort, k.registryName())
+		args = append(args, "--registry-create", fmt.Sprintf("%s:0.0.0.0:%s", k.registryName(), registryPort))
+	}
+
+	if install.DryRun != nil {
+		content := "# k3d " + strings.Join(args, " ")
+		if mirrors != "" {
+			content += " --registry-config registries.yaml\n# registries.yaml:\n" + mirrors
+		}
+		return install.DryRun.Step("k3d cluster", content)
+	}
+
+	if mirrors != "" {
+		registryConfig, err := os.CreateTemp("", "k3d-registries-*.yaml")
+		if err != nil {
+			return fmt.Errorf("failed to write registry config: %w", err)
+		}
+		defer os.Remove(registryConfig.Name())
+		fmt.Fprint(registryConfig, mirrors)
+		registryConfig.Close()
+
+		args = append(args, "--registry-config", registryConfig.Name())
+	}
+
+	createCluster := exec.CommandContext(ctx, "k3d", args...)
+	if err := runCommandWithOutput(createCluster); err != nil {
+		return fmt.Errorf("failed to create k3d cluster %s: %w", k.opts.Name, err)
+	}
+	return nil
+}
+
+// Delete removes the k3d cluster, its registry and the kubeconfig context
+func (k *Provider) Delete(ctx context.Context) error {
+	exists, err := k.Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		fmt.Println("🗑️ Deleting k3d cluster " + k.opts.Name + "...")
+		deleteCluster := exec.CommandContext(ctx, "k3d", "cluster", "delete", k.opts.Name)
+		if err := runCommandWithOutput(deleteCluster); err != nil {
+			return fmt.Errorf("failed to delete k3d cluster %s: %w", k.opts.Name, err)
+		}
+	} else {
+		fmt.Println("    k3d cluster " + k.opts.Name + " not found, skipping")
+	}
+
+	registries, err := exec.CommandContext(ctx, "k3d", "registry", "list", "--output", "json").Output()
+	if err != nil {
+		return fmt.Errorf("unable to get k3d registries: %w", err)
+	}
+	if strings.Contains(string(registries), `"`+k.registryName()+`"`) {
+		fmt.Println("💽 Deleting local registry...")
+		deleteRegistry := exec.CommandContext(ctx, "k3d", "registry", "delete", k.registryName())
+		if err := runCommandWithOutput(deleteRegistry); err != nil {
+			return fmt.Errorf("failed to delete k3d registry %s: %w", k.registryName(), err)
+		}
+	}
+
+	return quickstart.DeleteKubeContext(ctx, k.KubeContext())
+}
+
+// ConfigureRegistry returns the registry address to skip tag resolution for. The
+// registry itself is created and connected together with the cluster.
+func (k *Provider) ConfigureRegistry(ctx context.Context) (string, error) {
+	if !k.opts.Registry {
+		return "", nil
+	}
+	return "localhost:" + registryPort, nil
+}
+
+// ConfigureIngress exposes the networking layer through the NodePort mapped to the
+// host. The port mapping works the same way as for kind.
+func (k *Provider) ConfigureIngress(ctx context.Context) error {
+	return install.Ingress.ExposeNodePort(ctx, "127.0.0.1")
+}
+
+// Clusters returns the names of all existing k3d clusters
+func Clusters(ctx context.Context) ([]string, error) {
+	out, err := exec.CommandContext(ctx, "k3d", "cluster", "list", "--output", "json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get k3d clusters: %w", err)
+	}
+	return parseClusterNames(out)
+}
+
+// parseClusterNames extracts the cluster names from `k3d cluster list --output json`
+func parseClusterNames(out []byte) ([]string, error) {
+	var clusters []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(out, &clusters); err != nil {
+		return nil, fmt.Errorf("unable to parse k3d clusters: %w", err)
+	}
+	names := make([]string, 0, len(clusters))
+	for _, c := range clusters {
+		names = append(names, c.Name)
+	}
+	return names, nil
+}
+
+// RegistryRunning reports whether the registry container of the given cluster is
+// running, and whether it exists
+func RegistryRunning(ctx context.Context, name string) (running, found bool, err error) {
+	dcli, err := kind.CheckDocker(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	info, err := dcli.ContainerInspect(ctx, RegistryName(name))
+	if err != nil {
+		if dclient.IsErrNotFound(err) {
+			return false, false, nil
+		}
+		return false, false, fmt.Errorf("failed to inspect registry container: %w", err)
+	}
+	return info.State != nil && info.State.Running, true, nil
+}
+
+// IngressHostPort returns the host port mapped to the ingress NodePort of the given
+// cluster's first server node
+func IngressHostPort(ctx context.Context, name string) (int, error) {
+	dcli, err := kind.CheckDocker(ctx)
+	if err != nil {
+		return 0, err
+	}
+	info, err := dcli.ContainerInspect(ctx, "k3d-"+name+"-server-0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect server of k3d cluster %s: %w", name, err)
+	}
+	if info.HostConfig != nil {
+		for _, binding := range info.HostConfig.PortBindings["31080/tcp"] {
+			return strconv.Atoi(binding.HostPort)
+		}
+	}
+	return 0, fmt.Errorf("k3d cluster %s has no host port mapped for the ingress", name)
+}
+
+// checkK3dVersion validates that the user has the correct version of k3d installed.
+// If not, it prompts the user to download a newer version before continuing.
+func (k *Provider) checkK3dVersion(ctx context.Context) error {
+	out, err := exec.CommandContext(ctx, "k3d", "version").CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) {
+		return &qerrors.PrerequisiteError{
+			Tool: "k3d",
+			Hint: "Download from https://k3d.io/#installation",
+			Err:  err,
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get k3d version: %w", err)
+	}
+
+	userK3dVersion, err := parseK3dVersion(string(out))
+	if err != nil {
+		return fmt.Errorf("unable to parse k3d version: %w", err)
+	}
+	fmt.Printf("    k3d version is: v%s\n", userK3dVersion)
+	if userK3dVersion.olderThan(k3dVersion) {
+		fmt.Printf("WARNING: We recommend at least k3d v%s, while you are using v%s\n", k3dVersion, userK3dVersion)
+		fmt.Println("You can download a newer version from https://github.com/k3d-io/k3d/releases")
+		resp, err := k.prompter.Confirm(prompt.VersionWarning, "Continue anyway? (not recommended)")
+		if err != nil {
+			return err
+		}
+		if !resp {
+			return &qerrors.VersionError{Tool: "k3d", Version: userK3dVersion.float(), Minimum: k3dVersion.float()}
+		}
+	}
+	return nil
+}
+
+// release is the major and minor version of k3d. They are compared as numbers, as
+// floats would order v5.10 before v5.7.
+type release struct {
+	major, minor int
+}
+
+func (r release) olderThan(o release) bool {
+	return r.major < o.major || (r.major == o.major && r.minor < o.minor)
+}
+
+func (r release) String() string {
+	return fmt.Sprintf("%d.%d", r.major, r.minor)
+}
+
+// float returns the version as reported by errors.VersionError
+func (r release) float() float64 {
+	f, _ := strconv.ParseFloat(r.String(), 64)
+	return f
+}
+
+// parseK3dVersion parses the major and minor version from `k3d version`, which
+// prints e.g. "k3d version v5.7.4" followed by the k3s version
+func parseK3dVersion(v string) (release, error) {
+	m := regexp.MustCompile(`k3d version v(\d+)\.(\d+)`).FindStringSubmatch(v)
+	if m == nil {
+		return release{}, fmt.Errorf("unexpected version output %q", strings.TrimSpace(v))
+	}
+	major, err := strconv.Atoi(m[1])
+	if err != nil {
+		return release{}, err
+	}
+	minor, err := strconv.Atoi(m[2])
+	if err != nil {
+		return release{}, err
+	}
+	return release{major, minor}, nil
+}
+
+func runCommandWithOutput(c *exec.Cmd) error {
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("piping output: %w", err)
+	}
+	fmt.Print("\n")
+	return nil
+}
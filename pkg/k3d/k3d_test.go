@@ -0,0 +1,72 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k3d
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseK3dVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		out   string
+		want  release
+		older bool
+		err   string
+	}{
+		{"current", "k3d version v5.7.4\nk3s version v1.30.4-k3s1 (default)\n", release{5, 7}, false, ""},
+		{"two digit minor", "k3d version v5.10.0\nk3s version v1.33.1-k3s1 (default)\n", release{5, 10}, false, ""},
+		{"older minor", "k3d version v5.6.3\n", release{5, 6}, true, ""},
+		{"older major", "k3d version v4.4.8\n", release{4, 4}, true, ""},
+		{"newer major", "k3d version v6.0.0\n", release{6, 0}, false, ""},
+		{"unexpected", "k3d: command not found\n", release{}, false, `unexpected version output "k3d: command not found"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseK3dVersion(tc.out)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, got, tc.want)
+			assert.Equal(t, got.olderThan(k3dVersion), tc.older)
+		})
+	}
+}
+
+func TestParseClusterNames(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		out  string
+		want []string
+		err  string
+	}{
+		{"none", "[]", []string{}, ""},
+		{"clusters", `[{"name": "knative", "serversCount": 1, "nodes": [{"name": "k3d-knative-server-0"}]}, {"name": "dev"}]`, []string{"knative", "dev"}, ""},
+		{"invalid", "No clusters found", nil, "unable to parse k3d clusters"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseClusterNames([]byte(tc.out))
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, tc.want)
+		})
+	}
+}
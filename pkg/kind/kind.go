@@ -18,19 +18,26 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
 	dclient "github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
+	qerrors "knative.dev/kn-plugin-quickstart/pkg/errors"
 	"knative.dev/kn-plugin-quickstart/pkg/install"
+	"knative.dev/kn-plugin-quickstart/pkg/prompt"
+	"knative.dev/kn-plugin-quickstart/pkg/quickstart"
 )
 
 // NOTE: If you are changing kindVersion, please also update the kubectl and
@@ -42,123 +49,347 @@ import (
 // verify-min-k8s-version CI check enforces this.
 var (
 	kubernetesVersion  = "kindest/node:v1.34.0"
-	clusterName        string
 	kindVersion        = 0.30
 	container_reg_name = "kind-registry"
 	container_reg_port = "5001"
-	installKnative     = true
 )
 
-// SetUp creates a local Kind cluster and installs all the relevant Knative components
-func SetUp(name, kVersion string, installServing, installEventing, installKindRegistry bool, installKindExtraMountHostPath string, installKindExtraMountContainerPath string, hostPort int) error {
-	start := time.Now()
+// RegistryImage is the image of the local registry container
+const RegistryImage = "docker.io/library/registry:2"
+
+// registryLabel marks the local registry containers quickstart created. Registries
+// created otherwise, e.g. by kind's local registry script, have the same name.
+const registryLabel = "dev.knative.kn-quickstart"
+
+// Options configures the Kind cluster created by quickstart
+type Options struct {
+	Name                    string
+	KubernetesVersion       string
+	Registry                bool
+	ExtraMountHostPath      string
+	ExtraMountContainerPath string
+	HostPort                int
+	// NodeImageArchive is a docker save archive loaded into Docker before the cluster
+	// is created, so that the node image does not need to be pulled
+	NodeImageArchive string
+	// ImageArchive is a docker save archive loaded into the nodes once the cluster
+	// is created
+	ImageArchive string
+}
+
+// Provider is the quickstart.ClusterProvider creating Kind clusters
+type Provider struct {
+	opts     Options
+	prompter prompt.Prompter
+	dcli     *dclient.Client
+}
+
+var _ quickstart.ClusterProvider = (*Provider)(nil)
 
-	// if neither the "install-serving" or "install-eventing" flags are set,
-	// then we assume the user wants to install both serving and eventing
-	if !installServing && !installEventing {
-		installServing = true
-		installEventing = true
+// NewProvider returns a Provider for the Kind cluster described by opts
+func NewProvider(opts Options, p prompt.Prompter) *Provider {
+	opts.KubernetesVersion = NodeImage(opts.KubernetesVersion)
+	return &Provider{opts: opts, prompter: p}
+}
+
+// NodeImage returns the node image for the given Kubernetes version (1.x.y) or node
+// image. An empty version returns the default node image.
+func NodeImage(version string) string {
+	if version == "" {
+		return kubernetesVersion
+	} else if !strings.Contains(version, ":") {
+		return "kindest/node:v" + version
 	}
+	return version
+}
 
-	// kubectl is required, fail if not found
-	if _, err := exec.LookPath("kubectl"); err != nil {
-		fmt.Println("ERROR: kubectl is required for quickstart")
-		fmt.Println("Download from https://kubectl.docs.kubernetes.io/installation/kubectl/")
-		os.Exit(1)
+// Name returns the provider name
+func (k *Provider) Name() string {
+	return "kind"
+}
+
+// ClusterName returns the name of the Kind cluster
+func (k *Provider) ClusterName() string {
+	return k.opts.Name
+}
+
+// KubeContext returns the kubeconfig context Kind creates for the cluster
+func (k *Provider) KubeContext() string {
+	return "kind-" + k.opts.Name
+}
+
+// Preflight checks that Docker is running and Kind is recent enough
+func (k *Provider) Preflight(ctx context.Context) error {
+	if _, err := k.docker(ctx); err != nil {
+		return err
 	}
 
-	clusterName = name
-	if kVersion != "" {
-		if strings.Contains(kVersion, ":") {
-			kubernetesVersion = kVersion
-		} else {
-			kubernetesVersion = "kindest/node:v" + kVersion
-		}
+	fmt.Println("✅ Checking dependencies...")
+	if err := k.checkKindVersion(ctx); err != nil {
+		return fmt.Errorf("kind version check: %w", err)
+	}
+	if !k.opts.Registry {
+		// temporary warning that registry creation is now opt-in
+		// remove in v1.12
+		fmt.Println("\nA local registry is no longer created by default.")
+		fmt.Print("    To create a local registry, use the --registry flag.\n\n")
 	}
+	return nil
+}
 
-	if err := createKindCluster(installKindRegistry, installKindExtraMountHostPath, installKindExtraMountContainerPath, hostPort); err != nil {
-		return fmt.Errorf("failed to create kind cluster: %w", err)
-	}
-	if installKnative {
-		if installServing {
-			// Disable tag resolution for localhost registry, since there's no
-			// way to redirect Knative Serving to use the kind-registry name.
-			// See https://github.com/knative-extensions/kn-plugin-quickstart/issues/467
-			registries := ""
-			if installKindRegistry {
-				registries = fmt.Sprintf("localhost:%s", container_reg_port)
-			}
-			if err := install.Serving(registries); err != nil {
-				return fmt.Errorf("failed to install serving to kind cluster %s: %w", clusterName, err)
-			}
-			if err := install.Kourier(); err != nil {
-				return fmt.Errorf("failed to install kourier to kind cluster %s: %w", clusterName, err)
-			}
-			if err := install.KourierKind(); err != nil {
-				return fmt.Errorf("failed while configuring kourier for kind cluster %s: %w", clusterName, err)
-			}
+// Exists reports whether the Kind cluster is present
+func (k *Provider) Exists(ctx context.Context) (bool, error) {
+	getClusters := exec.CommandContext(ctx, "kind", "get", "clusters", "-q")
+	out, err := getClusters.CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("unable to get kind clusters: %w", err)
+	}
+	r := regexp.MustCompile(fmt.Sprintf(`(?m)^%s$`, regexp.QuoteMeta(k.opts.Name)))
+	return r.Match(out), nil
+}
+
+// Create creates a new Kind cluster
+func (k *Provider) Create(ctx context.Context) error {
+	extraMount := ""
+	if k.opts.ExtraMountHostPath != "" && k.opts.ExtraMountContainerPath != "" {
+		extraMount = fmt.Sprintf(`extraMounts:
+  - hostPath: %s
+    containerPath: %s`, k.opts.ExtraMountHostPath, k.opts.ExtraMountContainerPath)
+	}
+
+	if extraMount == "" {
+		fmt.Println("☸ Creating Kind cluster...")
+	} else {
+		fmt.Println("☸ Creating Kind cluster with extraMounts...")
+	}
+
+	imageString := ""
+	if k.opts.KubernetesVersion != "" {
+		imageString = fmt.Sprintf(`image: %s`, k.opts.KubernetesVersion)
+	}
+
+	config := fmt.Sprintf(`
+kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+name: %s
+containerdConfigPatches:
+- |-
+  [plugins."io.containerd.grpc.v1.cri".registry]
+    config_path = "/etc/containerd/certs.d/"
+nodes:
+- role: control-plane
+  %s
+  %s
+  extraPortMappings:
+  - containerPort: 31080
+    listenAddress: 0.0.0.0
+    hostPort: %d`, k.opts.Name, imageString, extraMount, k.opts.HostPort)
+
+	wait := "--wait=" + install.WaitTimeout(ctx, 120*time.Second).String()
+	if install.DryRun != nil {
+		return install.DryRun.Step("kind cluster", "# kind create cluster "+wait+" --config=-"+config)
+	}
+
+	if k.opts.NodeImageArchive != "" {
+		fmt.Println("📦 Loading node image...")
+		dcli, err := k.docker(ctx)
+		if err != nil {
+			return err
 		}
-		if installEventing {
-			if err := install.Eventing(); err != nil {
-				return fmt.Errorf("failed to install eventing to king cluster %s: %w", clusterName, err)
-			}
+		if err := loadImageArchive(ctx, dcli, k.opts.NodeImageArchive); err != nil {
+			return err
+		}
+	}
+
+	createCluster := exec.CommandContext(ctx, "kind", "create", "cluster", wait, "--config=-")
+	createCluster.Stdin = strings.NewReader(config)
+	if err := runCommandWithOutput(createCluster); err != nil {
+		return fmt.Errorf("failed to create kind cluster %s: %w", k.opts.Name, err)
+	}
+
+	if k.opts.ImageArchive != "" {
+		fmt.Println("📦 Loading images into the cluster nodes...")
+		loadImages := exec.CommandContext(ctx, "kind", "load", "image-archive", k.opts.ImageArchive, "--name", k.opts.Name)
+		if err := runCommandWithOutput(loadImages); err != nil {
+			return fmt.Errorf("failed to load images into kind cluster %s: %w", k.opts.Name, err)
 		}
 	}
 
-	finish := time.Since(start).Round(time.Second)
-	fmt.Printf("🚀 Knative install took: %s \n", finish)
-	fmt.Println("🎉 Now have some fun with Serverless and Event Driven Apps!")
 	return nil
 }
 
-func createKindCluster(registry bool, extraMountHostPath string, extraMountContainerPath string, hostPort int) error {
-	dcli, err := checkDocker()
+// loadImageArchive loads a docker save archive into Docker
+func loadImageArchive(ctx context.Context, dcli *dclient.Client, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open image archive: %w", err)
+	}
+	defer f.Close()
+	resp, err := dcli.ImageLoad(ctx, f, true)
+	if err != nil {
+		return fmt.Errorf("failed to load image archive %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return fmt.Errorf("failed to load image archive %s: %w", path, err)
+	}
+	return nil
+}
+
+// Delete removes the Kind cluster, the local registry container and the kubeconfig
+// context created by quickstart
+func (k *Provider) Delete(ctx context.Context) error {
+	dcli, err := k.docker(ctx)
 	if err != nil {
 		return err
 	}
-	fmt.Println("✅ Checking dependencies...")
-	if err := checkKindVersion(); err != nil {
-		return fmt.Errorf("unable to check kind version: %w", err)
+
+	exists, err := k.Exists(ctx)
+	if err != nil {
+		return err
 	}
-	if registry {
-		fmt.Println("💽 Installing local registry...")
-		if err := pullLocalRegistryImage(dcli); err != nil {
-			return fmt.Errorf("%w", err)
-		}
-		if err := createLocalRegistry(dcli); err != nil {
-			return fmt.Errorf("%w", err)
+	if exists {
+		fmt.Println("🗑️ Deleting Kind cluster " + k.opts.Name + "...")
+		deleteCluster := exec.CommandContext(ctx, "kind", "delete", "cluster", "--name", k.opts.Name)
+		if err := runCommandWithOutput(deleteCluster); err != nil {
+			return fmt.Errorf("failed to delete kind cluster %s: %w", k.opts.Name, err)
 		}
 	} else {
-		// temporary warning that registry creation is now opt-in
-		// remove in v1.12
-		fmt.Println("\nA local registry is no longer created by default.")
-		fmt.Print("    To create a local registry, use the --registry flag.\n\n")
+		fmt.Println("    Kind cluster " + k.opts.Name + " not found, skipping")
 	}
 
-	if err := checkForExistingCluster(registry, extraMountHostPath, extraMountContainerPath, hostPort); err != nil {
-		return fmt.Errorf("failed while handling or checking for existing kind cluster: %w", err)
+	if err := deleteLocalRegistry(ctx, dcli); err != nil {
+		return err
 	}
 
-	return nil
+	return quickstart.DeleteKubeContext(ctx, k.KubeContext())
+}
+
+// ConfigureRegistry creates the local registry and connects it to the Kind network
+func (k *Provider) ConfigureRegistry(ctx context.Context) (string, error) {
+	if !k.opts.Registry {
+		return "", nil
+	}
+
+	if install.DryRun != nil {
+		if err := install.DryRun.Step("registry container", registryContainerSpec()); err != nil {
+			return "", err
+		}
+		if err := install.DryRun.Resources("local-registry-hosting", localRegistryHosting()); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("localhost:%s", container_reg_port), nil
+	}
+
+	fmt.Println("💽 Installing local registry...")
+	dcli, err := k.docker(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := pullLocalRegistryImage(ctx, dcli); err != nil {
+		return "", err
+	}
+	if err := createLocalRegistry(ctx, dcli); err != nil {
+		return "", err
+	}
+	if err := k.connectLocalRegistry(ctx); err != nil {
+		return "", fmt.Errorf("local-registry: %w", err)
+	}
+
+	// Disable tag resolution for localhost registry, since there's no
+	// way to redirect Knative Serving to use the kind-registry name.
+	// See https://github.com/knative-extensions/kn-plugin-quickstart/issues/467
+	return fmt.Sprintf("localhost:%s", container_reg_port), nil
+}
+
+// ConfigureIngress exposes the networking layer through the NodePort mapped to the host
+func (k *Provider) ConfigureIngress(ctx context.Context) error {
+	return install.Ingress.ExposeNodePort(ctx, "127.0.0.1")
+}
+
+// Clusters returns the names of all existing Kind clusters
+func Clusters(ctx context.Context) ([]string, error) {
+	getClusters := exec.CommandContext(ctx, "kind", "get", "clusters", "-q")
+	out, err := getClusters.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get kind clusters: %w", err)
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// RegistryRunning reports whether the local registry container exists and is running
+func RegistryRunning(ctx context.Context) (bool, error) {
+	dcli, err := CheckDocker(ctx)
+	if err != nil {
+		return false, err
+	}
+	info, err := dcli.ContainerInspect(ctx, container_reg_name)
+	if err != nil {
+		if dclient.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to inspect registry container: %w", err)
+	}
+	return info.State != nil && info.State.Running, nil
+}
+
+// IngressHostPort returns the host port mapped to the ingress NodePort of the given
+// cluster's control plane node
+func IngressHostPort(ctx context.Context, name string) (int, error) {
+	dcli, err := CheckDocker(ctx)
+	if err != nil {
+		return 0, err
+	}
+	info, err := dcli.ContainerInspect(ctx, name+"-control-plane")
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect control plane of kind cluster %s: %w", name, err)
+	}
+	if info.HostConfig != nil {
+		for _, binding := range info.HostConfig.PortBindings["31080/tcp"] {
+			return strconv.Atoi(binding.HostPort)
+		}
+	}
+	return 0, fmt.Errorf("kind cluster %s has no host port mapped for the ingress", name)
 }
 
-// checkDocker checks that Docker is running on the users local system.
-func checkDocker() (*dclient.Client, error) {
+// docker returns the Docker client of the provider, checking that Docker is running
+// when it is first used. Steps selected with --skip-step or --only-step may run
+// without the preflight step.
+func (k *Provider) docker(ctx context.Context) (*dclient.Client, error) {
+	if k.dcli == nil {
+		dcli, err := CheckDocker(ctx)
+		if err != nil {
+			return nil, err
+		}
+		k.dcli = dcli
+	}
+	return k.dcli, nil
+}
+
+// CheckDocker checks that Docker is running on the users local system, and returns
+// a client for it.
+func CheckDocker(ctx context.Context) (*dclient.Client, error) {
 	dcli, err := dclient.NewClientWithOpts(dclient.FromEnv, dclient.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
 
-	if _, err := dcli.Info(context.Background()); err != nil {
-		return nil, fmt.Errorf("failed to get Docker info: %w", err)
+	if _, err := dcli.Info(ctx); err != nil {
+		return nil, &qerrors.PrerequisiteError{
+			Tool: "a running Docker daemon",
+			Err:  fmt.Errorf("failed to get Docker info: %w", err),
+		}
 	}
 
 	return dcli, nil
 }
 
-func pullLocalRegistryImage(dcli *dclient.Client) error {
-	ctx := context.Background()
-	iorc, err := dcli.ImagePull(ctx, "docker.io/library/registry:2", image.PullOptions{})
+func pullLocalRegistryImage(ctx context.Context, dcli *dclient.Client) error {
+	// Use the image already present, e.g. loaded from an offline bundle
+	if _, _, err := dcli.ImageInspectWithRaw(ctx, RegistryImage); err == nil {
+		return nil
+	}
+	iorc, err := dcli.ImagePull(ctx, RegistryImage, image.PullOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to create local registry container: %w", err)
 	}
@@ -177,13 +408,25 @@ func pullLocalRegistryImage(dcli *dclient.Client) error {
 	return nil
 }
 
-func createLocalRegistry(dcli *dclient.Client) error {
-	if err := deleteContainerRegistry(dcli); err != nil {
-		return fmt.Errorf("failed to delete local registry: %w", err)
+// createLocalRegistry creates and starts the local registry container. An existing
+// registry, which other kind clusters may use, is started if needed and reused.
+func createLocalRegistry(ctx context.Context, dcli *dclient.Client) error {
+	info, err := dcli.ContainerInspect(ctx, container_reg_name)
+	switch {
+	case err == nil && info.State != nil && info.State.Running:
+		return nil
+	case err == nil:
+		if err := dcli.ContainerStart(ctx, container_reg_name, container.StartOptions{}); err != nil {
+			return fmt.Errorf("failed to start local registry container: %w", err)
+		}
+		return nil
+	case !dclient.IsErrNotFound(err):
+		return fmt.Errorf("failed to inspect registry container: %w", err)
 	}
 
-	resp, err := dcli.ContainerCreate(context.Background(), &container.Config{
-		Image: "registry:2",
+	resp, err := dcli.ContainerCreate(ctx, &container.Config{
+		Image:  RegistryImage,
+		Labels: map[string]string{registryLabel: "true"},
 	}, &container.HostConfig{
 		RestartPolicy: container.RestartPolicy{
 			Name: "always",
@@ -202,24 +445,37 @@ func createLocalRegistry(dcli *dclient.Client) error {
 		return fmt.Errorf("failed to create local registry container: %w", err)
 	}
 
-	if err := dcli.ContainerStart(context.Background(), resp.ID, container.StartOptions{}); err != nil {
+	if err := dcli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
 		return fmt.Errorf("failed to start local registry container: %w", err)
 	}
 	return nil
 }
 
-func connectLocalRegistry(dcli *dclient.Client) error {
-	err := patchKindNodes()
+func (k *Provider) connectLocalRegistry(ctx context.Context) error {
+	err := k.patchKindNodes(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to patch kind nodes: %w", err)
 	}
 
-	err = dcli.NetworkConnect(context.Background(), "kind", container_reg_name, nil)
-	if err != nil {
+	// A reused registry may be connected already
+	err = k.dcli.NetworkConnect(ctx, "kind", container_reg_name, nil)
+	if err != nil && !strings.Contains(strings.ToLower(err.Error()), "already exists") {
 		return fmt.Errorf("failed to connect local registry to kind network: %w", err)
 	}
 
-	cm := fmt.Sprintf(`
+	createLocalRegistryConfigMap := install.Kubectl(ctx, "apply", "-f", "-")
+
+	createLocalRegistryConfigMap.Stdin = strings.NewReader(localRegistryHosting())
+	if err := createLocalRegistryConfigMap.Run(); err != nil {
+		return fmt.Errorf("failed to create local registry config map: %w", err)
+	}
+	return nil
+}
+
+// localRegistryHosting returns the ConfigMap documenting the local registry, see
+// https://github.com/kubernetes/enhancements/tree/master/keps/sig-cluster-lifecycle/generic/1755-communicating-a-local-registry
+func localRegistryHosting() string {
+	return fmt.Sprintf(`
 apiVersion: v1
 kind: ConfigMap
 metadata:
@@ -229,200 +485,128 @@ data:
   localRegistryHosting.v1: |
     host: "localhost:%s"
     help: "https://kind.sigs.k8s.io/docs/user/local-registry/"`, container_reg_port)
-	createLocalRegistryConfigMap := exec.Command("kubectl", "apply", "-f", "-")
+}
 
-	createLocalRegistryConfigMap.Stdin = strings.NewReader(cm)
-	// }
-	if err := createLocalRegistryConfigMap.Run(); err != nil {
-		return fmt.Errorf("failed to create local registry config map: %w", err)
-	}
-	return nil
+// registryContainerSpec describes the local registry container and how the nodes are
+// connected to it, as created by ConfigureRegistry
+func registryContainerSpec() string {
+	return fmt.Sprintf(`# docker run -d --name %[1]s --label %[4]s=true --restart always --network bridge -p 0.0.0.0:%[2]s:5000 %[3]s
+# docker network connect kind %[1]s
+# On each node, /etc/containerd/certs.d/localhost:%[2]s/hosts.toml:
+#   [host."http://%[1]s:5000"]
+`, container_reg_name, container_reg_port, RegistryImage, registryLabel)
 }
 
-// checkKindVersion validates that the user has the correct version of Kind installed.
-// If not, it prompts the user to download a newer version before continuing.
-func checkKindVersion() error {
-	versionCheck := exec.Command("kind", "version", "-q")
-	out, err := versionCheck.CombinedOutput()
+// deleteLocalRegistry removes the local registry container once no other container,
+// i.e. no node of another kind cluster, is attached to the kind network. Registries
+// quickstart did not create are kept.
+func deleteLocalRegistry(ctx context.Context, dcli *dclient.Client) error {
+	info, err := dcli.ContainerInspect(ctx, container_reg_name)
+	if dclient.IsErrNotFound(err) {
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get kind version: %w", err)
+		return fmt.Errorf("failed to inspect registry container: %w", err)
 	}
-	fmt.Printf("    Kind version is: %s", string(out))
-
-	userKindVersion, err := parseKindVersion(string(out))
+	if info.Config == nil || info.Config.Labels[registryLabel] != "true" {
+		fmt.Println("    Local registry " + container_reg_name + " was not created by quickstart, keeping it")
+		return nil
+	}
+	users, err := kindNetworkContainers(ctx, dcli)
 	if err != nil {
-		return fmt.Errorf("unable to parse kind version: %w", err)
+		return err
 	}
-	if userKindVersion < kindVersion {
-		var resp string
-		fmt.Printf("WARNING: We recommend at least Kind v%.2f, while you are using v%.2f\n", kindVersion, userKindVersion)
-		fmt.Println("You can download a newer version from https://github.com/kubernetes-sigs/kind/releases")
-		fmt.Print("Continue anyway? (not recommended) [y/N]: ")
-		fmt.Scanf("%s", &resp)
-		if resp == "n" || resp == "N" {
-			fmt.Println("Installation stopped. Please upgrade kind and run again")
-			os.Exit(0)
-		}
+	if len(users) > 0 {
+		fmt.Println("    Local registry is still used by " + strings.Join(users, ", ") + ", keeping it")
+		return nil
 	}
 
+	fmt.Println("💽 Deleting local registry...")
+	if err := disconnectLocalRegistry(ctx, dcli); err != nil {
+		return err
+	}
+	if err := deleteContainerRegistry(ctx, dcli); err != nil {
+		return fmt.Errorf("failed to delete container registry: %w", err)
+	}
 	return nil
 }
 
-// checkForExistingCluster checks if the user already has a Kind cluster. If so, it provides
-// the option of deleting the existing cluster and recreating it. If not, it proceeds to
-// creating a new cluster
-func checkForExistingCluster(registry bool, extraMountHostPath string, extraMountContainerPath string, hostPort int) error {
-	getClusters := exec.Command("kind", "get", "clusters", "-q")
-	out, err := getClusters.CombinedOutput()
+// kindNetworkContainers returns the names of the containers attached to the kind
+// network, other than the local registry
+func kindNetworkContainers(ctx context.Context, dcli *dclient.Client) ([]string, error) {
+	kindNetwork, err := dcli.NetworkInspect(ctx, "kind", network.InspectOptions{})
+	if dclient.IsErrNotFound(err) {
+		return nil, nil
+	}
 	if err != nil {
-		return fmt.Errorf("unable to get kind clusters: %w", err)
-	}
-	// TODO Add tests for regex
-	r := regexp.MustCompile(fmt.Sprintf(`(?m)^%s\n`, clusterName))
-	matches := r.Match(out)
-	if matches {
-		var resp string
-		fmt.Print("\nKnative Cluster kind-" + clusterName + " already installed.\nDelete and recreate [y/N]: ")
-		fmt.Scanf("%s", &resp)
-		if resp == "y" || resp == "Y" {
-			if err := recreateCluster(registry, extraMountHostPath, extraMountContainerPath, hostPort); err != nil {
-				return fmt.Errorf("failed while recreating kind cluster %s: %w", clusterName, err)
-			}
-		} else {
-			fmt.Println("\n    Installation skipped")
-			checkKnativeNamespace := exec.Command("kubectl", "get", "namespaces")
-			output, err := checkKnativeNamespace.CombinedOutput()
-			namespaces := string(output)
-			if err != nil {
-				fmt.Println(string(output))
-				return fmt.Errorf("unable to get kubernetes namspaces for kind cluster %s: %w", clusterName, err)
-			}
-			if strings.Contains(namespaces, "knative") {
-				fmt.Print("Knative installation already exists.\nDelete and recreate the cluster [y/N]: ")
-				fmt.Scanf("%s", &resp)
-				if resp == "y" || resp == "Y" {
-					if err := recreateCluster(registry, extraMountHostPath, extraMountContainerPath, hostPort); err != nil {
-						return fmt.Errorf("failed to recreate kind cluster: %w", err)
-					}
-				} else {
-					fmt.Println("Skipping installation")
-					installKnative = false
-					return nil
-				}
-			}
-			return nil
-		}
-	} else {
-		dcli, err := dclient.NewClientWithOpts(dclient.FromEnv, dclient.WithAPIVersionNegotiation())
-		if err != nil {
-			return fmt.Errorf("failed to initialize new api client: %w", err)
-		}
-
-		if err := createNewCluster(extraMountHostPath, extraMountContainerPath, hostPort); err != nil {
-			return fmt.Errorf("%w", err)
-		}
-		if registry {
-			if err := createLocalRegistry(dcli); err != nil {
-				return fmt.Errorf("%w", err)
-			}
-			if err := connectLocalRegistry(dcli); err != nil {
-				return fmt.Errorf("local-registry: %w", err)
-			}
+		return nil, fmt.Errorf("failed to inspect kind network: %w", err)
+	}
+	var names []string
+	for _, c := range kindNetwork.Containers {
+		if c.Name != container_reg_name {
+			names = append(names, c.Name)
 		}
 	}
-
-	return nil
+	sort.Strings(names)
+	return names, nil
 }
 
-// recreateCluster recreates a Kind cluster
-func recreateCluster(registry bool, extraMountHostPath string, extraMountContainerPath string, hostPort int) error {
-	fmt.Println("\n    Deleting cluster...")
-
-	dcli, err := dclient.NewClientWithOpts(dclient.FromEnv, dclient.WithAPIVersionNegotiation())
-	if err != nil {
-		return fmt.Errorf("failed to initialize new api client: %w", err)
-	}
-
-	deleteCluster := exec.Command("kind", "delete", "cluster", "--name", clusterName)
-	if err := deleteCluster.Run(); err != nil {
-		return fmt.Errorf("failed to delete kind cluster %s: %w", clusterName, err)
-	}
-	if err := deleteContainerRegistry(dcli); err != nil {
-		return fmt.Errorf("failed to delete container registry: %w", err)
-	}
-	if err := createNewCluster(extraMountHostPath, extraMountContainerPath, hostPort); err != nil {
-		return fmt.Errorf("%w", err)
-	}
-	if registry {
-		if err := createLocalRegistry(dcli); err != nil {
-			return fmt.Errorf("%w", err)
-		}
-		if err := connectLocalRegistry(dcli); err != nil {
-			return fmt.Errorf("unable to connect local-registry: %w", err)
+// disconnectLocalRegistry detaches the registry container from the kind network,
+// ignoring registries or networks that no longer exist
+func disconnectLocalRegistry(ctx context.Context, dcli *dclient.Client) error {
+	if err := dcli.NetworkDisconnect(ctx, "kind", container_reg_name, true); err != nil {
+		if dclient.IsErrNotFound(err) || strings.Contains(strings.ToLower(err.Error()), "is not connected") {
+			return nil
 		}
+		return fmt.Errorf("failed to disconnect local registry from kind network: %w", err)
 	}
 	return nil
 }
 
-// createNewCluster creates a new Kind cluster
-func createNewCluster(extraMountHostPath string, extraMountContainerPath string, hostPort int) error {
-	extraMount := ""
-	if extraMountHostPath != "" && extraMountContainerPath != "" {
-		extraMount = fmt.Sprintf(`extraMounts:
-  - hostPath: %s
-    containerPath: %s`, extraMountHostPath, extraMountContainerPath)
+// checkKindVersion validates that the user has the correct version of Kind installed.
+// If not, it prompts the user to download a newer version before continuing.
+func (k *Provider) checkKindVersion(ctx context.Context) error {
+	versionCheck := exec.CommandContext(ctx, "kind", "version", "-q")
+	out, err := versionCheck.CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) {
+		return &qerrors.PrerequisiteError{
+			Tool: "kind",
+			Hint: "Download from https://kind.sigs.k8s.io/docs/user/quick-start",
+			Err:  err,
+		}
 	}
-
-	if extraMount == "" {
-		fmt.Println("☸ Creating Kind cluster...")
-	} else {
-		fmt.Println("☸ Creating Kind cluster with extraMounts...")
+	if err != nil {
+		return fmt.Errorf("failed to get kind version: %w", err)
 	}
+	fmt.Printf("    Kind version is: %s", string(out))
 
-	imageString := ""
-	if kubernetesVersion != "" {
-		imageString = fmt.Sprintf(`image: %s`, kubernetesVersion)
+	userKindVersion, err := parseKindVersion(string(out))
+	if err != nil {
+		return fmt.Errorf("unable to parse kind version: %w", err)
 	}
-
-	config := fmt.Sprintf(`
-kind: Cluster
-apiVersion: kind.x-k8s.io/v1alpha4
-name: %s
-containerdConfigPatches:
-- |-
-  [plugins."io.containerd.grpc.v1.cri".registry]
-    config_path = "/etc/containerd/certs.d/"
-nodes:
-- role: control-plane
-  %s
-  %s
-  extraPortMappings:
-  - containerPort: 31080
-    listenAddress: 0.0.0.0
-    hostPort: %d`, clusterName, imageString, extraMount, hostPort)
-
-	createCluster := exec.Command("kind", "create", "cluster", "--wait=120s", "--config=-")
-	createCluster.Stdin = strings.NewReader(config)
-	if err := runCommandWithOutput(createCluster); err != nil {
-		return fmt.Errorf("failed to create kind cluster %s: %w", clusterName, err)
+	if userKindVersion < kindVersion {
+		fmt.Printf("WARNING: We recommend at least Kind v%.2f, while you are using v%.2f\n", kindVersion, userKindVersion)
+		fmt.Println("You can download a newer version from https://github.com/kubernetes-sigs/kind/releases")
+		resp, err := k.prompter.Confirm(prompt.VersionWarning, "Continue anyway? (not recommended)")
+		if err != nil {
+			return err
+		}
+		if !resp {
+			return &qerrors.VersionError{Tool: "kind", Version: userKindVersion, Minimum: kindVersion}
+		}
 	}
 
 	return nil
 }
 
-func patchKindNodes() error {
-	getNodes := exec.Command("kind", "get", "nodes", "--name", clusterName)
+func (k *Provider) patchKindNodes(ctx context.Context) error {
+	getNodes := exec.CommandContext(ctx, "kind", "get", "nodes", "--name", k.opts.Name)
 	out, err := getNodes.Output()
 	if err != nil {
 		return fmt.Errorf("failed to get kind nodes: %w", err)
 	}
 
 	nodes := strings.Split(strings.TrimSpace(string(out)), "\n")
-	dcli, err := dclient.NewClientWithOpts(dclient.FromEnv, dclient.WithAPIVersionNegotiation())
-	if err != nil {
-		return fmt.Errorf("failed to create Docker client: %w", err)
-	}
-
 	for _, node := range nodes {
 		fmt.Println("🔗 Patching node: " + node) // DEBUG
 		reg_config_dir := fmt.Sprintf("/etc/containerd/certs.d/localhost:%s/", container_reg_port)
@@ -432,12 +616,12 @@ func patchKindNodes() error {
 			Tty:    false,
 		}
 
-		execIDResp, err := dcli.ContainerExecCreate(context.Background(), node, execOpts)
+		execIDResp, err := k.dcli.ContainerExecCreate(ctx, node, execOpts)
 		if err != nil {
 			return fmt.Errorf("failed to create exec instance on node %s: %w", node, err)
 		}
 
-		if err := dcli.ContainerExecStart(context.Background(), execIDResp.ID, container.ExecStartOptions{
+		if err := k.dcli.ContainerExecStart(ctx, execIDResp.ID, container.ExecStartOptions{
 			Detach: true,
 			Tty:    false,
 		}); err != nil {
@@ -467,8 +651,8 @@ func parseKindVersion(v string) (float64, error) {
 	return floatVersion, nil
 }
 
-func deleteContainerRegistry(dcli *dclient.Client) error {
-	if err := dcli.ContainerRemove(context.Background(), container_reg_name, container.RemoveOptions{Force: true}); err != nil {
+func deleteContainerRegistry(ctx context.Context, dcli *dclient.Client) error {
+	if err := dcli.ContainerRemove(ctx, container_reg_name, container.RemoveOptions{Force: true}); err != nil {
 		if strings.Contains(strings.ToLower(err.Error()), ": no such container") {
 			return nil
 		}
@@ -15,16 +15,21 @@
 package minikube
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
-	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	qerrors "knative.dev/kn-plugin-quickstart/pkg/errors"
 	"knative.dev/kn-plugin-quickstart/pkg/install"
+	"knative.dev/kn-plugin-quickstart/pkg/prompt"
+	"knative.dev/kn-plugin-quickstart/pkg/quickstart"
 )
 
 // NOTE: If you are changing minikubeVersion, please also update the kubectl and
@@ -36,229 +41,202 @@ import (
 // leading "v" — minikube's --kubernetes-version flag expects bare semver).
 // The verify-min-k8s-version CI check enforces this.
 var kubernetesVersion = "1.34.0"
-var clusterName string
-var clusterVersionOverride bool
 var minikubeVersion = 1.37
 var cpus = "3"
 var memory = "3072"
-var installKnative = true
-var customMinikubeArgs = []string{}
-
-// SetUp creates a local Minikube cluster and installs all the relevant Knative components
-func SetUp(name, kVersion string, installServing, installEventing bool, minikubeArgs []string) error {
-	start := time.Now()
-
-	// if neither the "install-serving" or "install-eventing" flags are set,
-	// then we assume the user wants to install both serving and eventing
-	if !installServing && !installEventing {
-		installServing = true
-		installEventing = true
-	}
-
-	// kubectl is required, fail if not found
-	if _, err := exec.LookPath("kubectl"); err != nil {
-		fmt.Println("ERROR: kubectl is required for quickstart")
-		fmt.Println("Download from https://kubectl.docs.kubernetes.io/installation/kubectl/")
-		os.Exit(1)
-	}
 
-	clusterName = name
-	if len(minikubeArgs) > 0 {
-		customMinikubeArgs = minikubeArgs
-		// check custom flags for name, as that takes precedent and affects functionality the most (most recent)
-		for i, arg := range slices.Backward(minikubeArgs) {
-			customArg, value := parseArg(arg)
-			if customArg == "-p" || customArg == "--profile" {
-				// use value from equal sign if it is there
-				if value != "" {
-					clusterName = value
-				} else if i+1 >= len(customMinikubeArgs) {
-					// edge case, but continue if not filled in and let minikube throw an error for empty arg
-					continue
-				} else {
-					clusterName = customMinikubeArgs[i+1]
-				}
-				break
-			}
-		}
-	}
+// Options configures the Minikube cluster created by quickstart
+type Options struct {
+	Name              string
+	KubernetesVersion string
+	// Args are extra arguments passed to `minikube start`
+	Args []string
+}
 
-	if kVersion != "" {
-		kubernetesVersion = kVersion
-		clusterVersionOverride = true
-	}
+// Provider is the quickstart.ClusterProvider creating Minikube clusters
+type Provider struct {
+	opts     Options
+	prompter prompt.Prompter
+}
 
-	if err := createMinikubeCluster(); err != nil {
-		return fmt.Errorf("failed to create minikube cluster: %w", err)
-	}
-	fmt.Print("\n")
-	fmt.Println("To finish setting up networking for minikube, run the following command in a separate terminal window:")
-	fmt.Println("    minikube tunnel --profile knative")
-	fmt.Println("The tunnel command must be running in a terminal window any time when using the knative quickstart environment.")
-	fmt.Println("\nPress the Enter key to continue")
-	fmt.Scanln()
-	if installKnative {
-		if installServing {
-			if err := install.Serving(""); err != nil {
-				return fmt.Errorf("failed to install serving to minikube cluster %s: %w", clusterName, err)
-			}
-			if err := install.Kourier(); err != nil {
-				return fmt.Errorf("failed to install kourier to minikube cluster %s: %w", clusterName, err)
-			}
-			if err := install.KourierMinikube(); err != nil {
-				return fmt.Errorf("failed while configuring kourier for minikube cluster %s: %w", clusterName, err)
-			}
-		}
-		if installEventing {
-			if err := install.Eventing(); err != nil {
-				return fmt.Errorf("failed to install eventing to minikube cluster %s: %w", clusterName, err)
+var _ quickstart.ClusterProvider = (*Provider)(nil)
+
+// NewProvider returns a Provider for the Minikube cluster described by opts
+func NewProvider(opts Options, p prompt.Prompter) *Provider {
+	// check custom flags for name, as that takes precedent and affects functionality the most (most recent)
+	for i, arg := range slices.Backward(opts.Args) {
+		customArg, value := parseArg(arg)
+		if customArg == "-p" || customArg == "--profile" {
+			// use value from equal sign if it is there
+			if value != "" {
+				opts.Name = value
+			} else if i+1 >= len(opts.Args) {
+				// edge case, but continue if not filled in and let minikube throw an error for empty arg
+				continue
+			} else {
+				opts.Name = opts.Args[i+1]
 			}
+			break
 		}
 	}
+	return &Provider{opts: opts, prompter: p}
+}
 
-	finish := time.Since(start).Round(time.Second)
-	fmt.Printf("🚀 Knative install took: %s \n", finish)
-	fmt.Println("🎉 Now have some fun with Serverless and Event Driven Apps!")
-
-	return nil
+// Name returns the provider name
+func (m *Provider) Name() string {
+	return "minikube"
 }
 
-func createMinikubeCluster() error {
-	if err := checkMinikubeVersion(); err != nil {
-		return fmt.Errorf("unable to get minikube version: %w", err)
-	}
-	if err := checkForExistingCluster(); err != nil {
-		return fmt.Errorf("failure while handling or checking for existing minikube cluster: %w", err)
-	}
-	return nil
+// ClusterName returns the name of the Minikube profile
+func (m *Provider) ClusterName() string {
+	return m.opts.Name
 }
 
-// checkMinikubeVersion validates that the user has the correct version of Minikube installed.
-// If not, it prompts the user to download a newer version before continuing.
-func checkMinikubeVersion() error {
-	versionCheck := exec.Command("minikube", "version", "--short")
-	out, err := versionCheck.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("unable to check minikube version: %w", err)
-	}
-	fmt.Printf("Minikube version is: %s\n", string(out))
+// KubeContext returns the kubeconfig context Minikube creates for the profile
+func (m *Provider) KubeContext() string {
+	return m.opts.Name
+}
 
-	userMinikubeVersion, err := parseMinikubeVersion(string(out))
-	if err != nil {
-		return fmt.Errorf("unable to parse minikube version: %w", err)
-	}
-	if userMinikubeVersion < minikubeVersion {
-		var resp string
-		fmt.Printf("WARNING: We recommend at least Minikube v%.2f, while you are using v%.2f\n", minikubeVersion, userMinikubeVersion)
-		fmt.Println("You can download a newer version from https://github.com/kubernetes/minikube/releases/")
-		fmt.Print("Continue anyway? (not recommended) [y/N]: ")
-		fmt.Scanf("%s", &resp)
-		if strings.ToLower(resp) != "y" {
-			fmt.Println("Installation stopped. Please upgrade minikube and run again")
-			os.Exit(0)
-		}
+// Preflight checks that Minikube is installed and recent enough
+func (m *Provider) Preflight(ctx context.Context) error {
+	if err := m.checkMinikubeVersion(ctx); err != nil {
+		return fmt.Errorf("minikube version check: %w", err)
 	}
-
 	return nil
 }
 
-// checkForExistingCluster checks if the user already has a Minikube cluster. If so, it provides
-// the option of deleting the existing cluster and recreating it. If not, it proceeds to
-// creating a new cluster
-func checkForExistingCluster() error {
-	getClusters := exec.Command("minikube", "profile", "list")
-	out, err := getClusters.CombinedOutput()
+// Exists reports whether the Minikube profile is present
+func (m *Provider) Exists(ctx context.Context) (bool, error) {
+	names, err := Profiles(ctx)
 	if err != nil {
-		// there are no existing minikube profiles, the listing profiles command will error
-		// if there were no profiles, we simply want to create a new one and not stop the install
-		// so if the error is the "MK_USAGE_NO_PROFILE" error, we ignore it and continue onwards
-		if !strings.Contains(string(out), "MK_USAGE_NO_PROFILE") {
-			return fmt.Errorf("failed to get existing minikube profiles: %w", err)
-		}
-	}
-	// TODO Add tests for regex
-	r := regexp.MustCompile(clusterName)
-	matches := r.Match(out)
-	if matches {
-		var resp string
-		fmt.Print("Knative Cluster " + clusterName + " already installed.\nDelete and recreate [y/N]: ")
-		fmt.Scanf("%s", &resp)
-		if strings.ToLower(resp) != "y" {
-			fmt.Println("Installation skipped")
-			checkKnativeNamespace := exec.Command("kubectl", "get", "namespaces")
-			output, err := checkKnativeNamespace.CombinedOutput()
-			namespaces := string(output)
-			if err != nil {
-				fmt.Println(string(output))
-				return fmt.Errorf("unable to get existing kubernetes namespaces for minikube cluster %s: %w", clusterName, err)
-			}
-			if strings.Contains(namespaces, "knative") {
-				fmt.Print("Knative installation already exists.\nDelete and recreate the cluster [y/N]: ")
-				fmt.Scanf("%s", &resp)
-				if strings.ToLower(resp) != "y" {
-					fmt.Println("Skipping installation")
-					installKnative = false
-					return nil
-				} else {
-					if err := recreateCluster(); err != nil {
-						return fmt.Errorf("failed while recreating minikube cluster: %w", err)
-					}
-				}
-			}
-			return nil
-		}
-		if err := recreateCluster(); err != nil {
-			return fmt.Errorf("failed while recreating minikube cluster: %w", err)
-		}
-		return nil
+		return false, err
 	}
-
-	if err := createNewCluster(customMinikubeArgs); err != nil {
-		return fmt.Errorf("%w", err)
-	}
-
-	return nil
+	return slices.Contains(names, m.opts.Name), nil
 }
 
-// createNewCluster creates a new Minikube cluster
-func createNewCluster(minikubeArgs []string) error {
+// Create creates a new Minikube cluster
+func (m *Provider) Create(ctx context.Context) error {
 	fmt.Println("☸ Creating Minikube cluster...")
 
-	if !clusterVersionOverride {
+	kVersion := m.opts.KubernetesVersion
+	if kVersion == "" {
+		kVersion = kubernetesVersion
 		fmt.Println("\nUsing the standard minikube driver for your system")
 		fmt.Println("If you wish to use a different driver, please configure minikube using")
 		fmt.Print("    minikube config set driver <your-driver>\n\n")
 
 		// If minikube config kubernetes-version exists, use that instead of our default
-		if config, ok := getMinikubeConfig("kubernetes-version"); ok {
-			kubernetesVersion = config
+		if config, ok := getMinikubeConfig(ctx, "kubernetes-version"); ok {
+			kVersion = config
 		}
 	}
 
 	// get user configs for memory/cpus if they exist
-	if config, ok := getMinikubeConfig("cpus"); ok {
-		cpus = config
+	clusterCPUs, clusterMemory := cpus, memory
+	if config, ok := getMinikubeConfig(ctx, "cpus"); ok {
+		clusterCPUs = config
 	}
-	if config, ok := getMinikubeConfig("memory"); ok {
-		memory = config
+	if config, ok := getMinikubeConfig(ctx, "memory"); ok {
+		clusterMemory = config
 	}
 
 	// create cluster and wait until ready
-	createCluster := exec.Command("minikube", "start",
-		"--kubernetes-version", kubernetesVersion,
-		"--cpus", cpus,
-		"--memory", memory,
-		"--profile", clusterName,
+	createCluster := exec.CommandContext(ctx, "minikube", "start",
+		"--kubernetes-version", kVersion,
+		"--cpus", clusterCPUs,
+		"--memory", clusterMemory,
+		"--profile", m.opts.Name,
 		"--wait", "all",
+		"--wait-timeout", install.WaitTimeout(ctx, 6*time.Minute).String(),
 		"--insecure-registry", "10.0.0.0/24",
 		"--addons=registry")
 
-	if len(minikubeArgs) > 0 {
-		createCluster.Args = append(createCluster.Args, minikubeArgs...)
+	if len(m.opts.Args) > 0 {
+		createCluster.Args = append(createCluster.Args, m.opts.Args...)
+	}
+
+	if install.DryRun != nil {
+		return install.DryRun.Step("minikube start", "# "+strings.Join(createCluster.Args, " "))
 	}
 
 	if err := runCommandWithOutput(createCluster); err != nil {
-		return fmt.Errorf("failed to create new minikube cluster %s: %w", clusterName, err)
+		return fmt.Errorf("failed to create new minikube cluster %s: %w", m.opts.Name, err)
+	}
+
+	return nil
+}
+
+// Delete removes the Minikube profile and the kubeconfig context created by quickstart
+func (m *Provider) Delete(ctx context.Context) error {
+	exists, err := m.Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		fmt.Println("🗑️ Deleting Minikube cluster " + m.opts.Name + "...")
+		deleteCluster := exec.CommandContext(ctx, "minikube", "delete", "--profile", m.opts.Name)
+		if err := runCommandWithOutput(deleteCluster); err != nil {
+			return fmt.Errorf("failed to delete minikube cluster %s: %w", m.opts.Name, err)
+		}
+	} else {
+		fmt.Println("    Minikube profile " + m.opts.Name + " not found, skipping")
+	}
+
+	return quickstart.DeleteKubeContext(ctx, m.KubeContext())
+}
+
+// ConfigureRegistry does nothing, the registry addon is enabled when the cluster is created
+func (m *Provider) ConfigureRegistry(ctx context.Context) (string, error) {
+	return "", nil
+}
+
+// ConfigureIngress asks the user to start `minikube tunnel` and sets up the default
+// domain for the LoadBalancer of the networking layer
+func (m *Provider) ConfigureIngress(ctx context.Context) error {
+	if install.DryRun != nil {
+		return install.Ingress.ExposeLoadBalancer(ctx)
+	}
+	fmt.Print("\n")
+	fmt.Println("To finish setting up networking for minikube, run the following command in a separate terminal window:")
+	fmt.Println("    minikube tunnel --profile " + m.opts.Name)
+	fmt.Println("The tunnel command must be running in a terminal window any time when using the knative quickstart environment.")
+	if err := m.prompter.WaitForEnter("\nPress the Enter key to continue"); err != nil {
+		return err
+	}
+	return install.Ingress.ExposeLoadBalancer(ctx)
+}
+
+// checkMinikubeVersion validates that the user has the correct version of Minikube installed.
+// If not, it prompts the user to download a newer version before continuing.
+func (m *Provider) checkMinikubeVersion(ctx context.Context) error {
+	versionCheck := exec.CommandContext(ctx, "minikube", "version", "--short")
+	out, err := versionCheck.CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) {
+		return &qerrors.PrerequisiteError{
+			Tool: "minikube",
+			Hint: "Download from https://minikube.sigs.k8s.io/docs/start/",
+			Err:  err,
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to check minikube version: %w", err)
+	}
+	fmt.Printf("Minikube version is: %s\n", string(out))
+
+	userMinikubeVersion, err := parseMinikubeVersion(string(out))
+	if err != nil {
+		return fmt.Errorf("unable to parse minikube version: %w", err)
+	}
+	if userMinikubeVersion < minikubeVersion {
+		fmt.Printf("WARNING: We recommend at least Minikube v%.2f, while you are using v%.2f\n", minikubeVersion, userMinikubeVersion)
+		fmt.Println("You can download a newer version from https://github.com/kubernetes/minikube/releases/")
+		resp, err := m.prompter.Confirm(prompt.VersionWarning, "Continue anyway? (not recommended)")
+		if err != nil {
+			return err
+		}
+		if !resp {
+			return &qerrors.VersionError{Tool: "minikube", Version: userMinikubeVersion, Minimum: minikubeVersion}
+		}
 	}
 
 	return nil
@@ -285,9 +263,9 @@ func parseMinikubeVersion(v string) (float64, error) {
 	return floatVersion, nil
 }
 
-func getMinikubeConfig(k string) (string, bool) {
+func getMinikubeConfig(ctx context.Context, k string) (string, bool) {
 	var ok bool
-	getConfig := exec.Command("minikube", "config", "get", k)
+	getConfig := exec.CommandContext(ctx, "minikube", "config", "get", k)
 	v, err := getConfig.Output()
 	if err == nil {
 		ok = true
@@ -295,16 +273,36 @@ func getMinikubeConfig(k string) (string, bool) {
 	return strings.TrimRight(string(v), "\n"), ok
 }
 
-func recreateCluster() error {
-	fmt.Println("deleting cluster...")
-	deleteCluster := exec.Command("minikube", "delete", "--profile", clusterName)
-	if err := deleteCluster.Run(); err != nil {
-		return fmt.Errorf("failed to delete minikube cluster %s: %w", clusterName, err)
+// Profiles returns the names of all valid Minikube profiles
+func Profiles(ctx context.Context) ([]string, error) {
+	listProfiles := exec.CommandContext(ctx, "minikube", "profile", "list", "--output", "json")
+	out, err := listProfiles.Output()
+	if err != nil && len(out) == 0 {
+		// minikube exits non-zero when there are no profiles at all
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && strings.Contains(string(exitErr.Stderr), "MK_USAGE_NO_PROFILE") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get existing minikube profiles: %w", err)
+	}
+	return parseProfileNames(out)
+}
+
+// parseProfileNames extracts the valid profile names from `minikube profile list -o json`
+func parseProfileNames(out []byte) ([]string, error) {
+	var profiles struct {
+		Valid []struct {
+			Name string
+		} `json:"valid"`
 	}
-	if err := createNewCluster(customMinikubeArgs); err != nil {
-		return fmt.Errorf("%w", err)
+	if err := json.Unmarshal(out, &profiles); err != nil {
+		return nil, fmt.Errorf("unable to parse minikube profiles: %w", err)
 	}
-	return nil
+	names := make([]string, 0, len(profiles.Valid))
+	for _, p := range profiles.Valid {
+		names = append(names, p.Name)
+	}
+	return names, nil
 }
 
 func parseArg(arg string) (string, string) {
@@ -35,13 +35,12 @@ func (pl *plugin) Name() string {
 
 // Execute represents the plugin's entrypoint when called through kn
 func (pl *plugin) Execute(args []string) error {
-	cmd := root.NewRootCommand()
 	oldArgs := os.Args
 	defer (func() {
 		os.Args = oldArgs
 	})()
 	os.Args = append([]string{"kn-quickstart"}, args...)
-	return cmd.Execute()
+	return root.Execute()
 }
 
 // Description is displayed in kn's help message
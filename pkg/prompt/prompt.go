@@ -0,0 +1,161 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	qerrors "knative.dev/kn-plugin-quickstart/pkg/errors"
+)
+
+// ErrNonInteractive is returned when a question needs an answer from the user, but
+// stdin is not a terminal and no flag provides the answer
+var ErrNonInteractive = fmt.Errorf("%w: input required but stdin is not a terminal", qerrors.ErrAborted)
+
+// Question identifies the kind of question asked, so that it can be answered by flags
+type Question int
+
+const (
+	// Recreate asks whether an existing cluster should be deleted and recreated
+	Recreate Question = iota
+	// VersionWarning asks whether to continue with an outdated tool version
+	VersionWarning
+)
+
+// Options holds the answers given up front on the command line
+type Options struct {
+	// Yes answers yes to every question
+	Yes bool
+	// Recreate deletes and recreates existing clusters
+	Recreate bool
+	// ReuseExisting keeps existing clusters
+	ReuseExisting bool
+	// IgnoreVersionWarnings continues with outdated tool versions
+	IgnoreVersionWarnings bool
+}
+
+// Prompter asks the user questions, or answers them from Options when possible
+type Prompter interface {
+	// Confirm asks a yes/no question, defaulting to no
+	Confirm(q Question, message string) (bool, error)
+	// WaitForEnter shows the message and blocks until the user presses Enter
+	WaitForEnter(message string) error
+}
+
+type prompter struct {
+	opts        Options
+	in          *bufio.Reader
+	out         io.Writer
+	interactive bool
+}
+
+// New returns a Prompter reading from stdin, which is only asked when stdin is a terminal
+func New(opts Options) Prompter {
+	return NewWithIO(opts, os.Stdin, os.Stdout, isTerminal(os.Stdin))
+}
+
+// NewWithIO returns a Prompter using the given input and output
+func NewWithIO(opts Options, in io.Reader, out io.Writer, interactive bool) Prompter {
+	return &prompter{
+		opts:        opts,
+		in:          bufio.NewReader(in),
+		out:         out,
+		interactive: interactive,
+	}
+}
+
+func (p *prompter) Confirm(q Question, message string) (bool, error) {
+	fmt.Fprint(p.out, message+" [y/N]: ")
+	if answer, flag, ok := p.answer(q); ok {
+		fmt.Fprintf(p.out, "%s (%s)\n", yesNo(answer), flag)
+		return answer, nil
+	}
+	if !p.interactive {
+		fmt.Fprintln(p.out)
+		return false, fmt.Errorf("%w: %s", ErrNonInteractive, hint(q))
+	}
+	line, err := p.in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	resp := strings.ToLower(strings.TrimSpace(line))
+	return resp == "y" || resp == "yes", nil
+}
+
+func (p *prompter) WaitForEnter(message string) error {
+	fmt.Fprintln(p.out, message)
+	if p.opts.Yes {
+		return nil
+	}
+	if !p.interactive {
+		return fmt.Errorf("%w: use --yes to continue without waiting", ErrNonInteractive)
+	}
+	if _, err := p.in.ReadString('\n'); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	return nil
+}
+
+// answer returns the answer given by flags for the question, and the flag name
+func (p *prompter) answer(q Question) (bool, string, bool) {
+	switch q {
+	case Recreate:
+		if p.opts.Recreate {
+			return true, "--recreate", true
+		}
+		if p.opts.ReuseExisting {
+			return false, "--reuse-existing", true
+		}
+	case VersionWarning:
+		if p.opts.IgnoreVersionWarnings {
+			return true, "--ignore-version-warnings", true
+		}
+	}
+	if p.opts.Yes {
+		return true, "--yes", true
+	}
+	return false, "", false
+}
+
+func hint(q Question) string {
+	switch q {
+	case Recreate:
+		return "use --recreate or --reuse-existing to decide what happens to existing clusters"
+	case VersionWarning:
+		return "use --ignore-version-warnings to continue anyway"
+	default:
+		return "use --yes to answer yes to all questions"
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "y"
+	}
+	return "N"
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,71 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestConfirmFromFlags(t *testing.T) {
+	cases := []struct {
+		name     string
+		opts     Options
+		question Question
+		want     bool
+	}{
+		{"recreate", Options{Recreate: true}, Recreate, true},
+		{"reuse existing", Options{ReuseExisting: true, Yes: true}, Recreate, false},
+		{"ignore version warnings", Options{IgnoreVersionWarnings: true}, VersionWarning, true},
+		{"yes", Options{Yes: true}, VersionWarning, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewWithIO(tc.opts, strings.NewReader(""), new(bytes.Buffer), false)
+			got, err := p.Confirm(tc.question, "Continue?")
+			assert.NilError(t, err)
+			assert.Equal(t, got, tc.want)
+		})
+	}
+}
+
+func TestConfirmInteractive(t *testing.T) {
+	p := NewWithIO(Options{}, strings.NewReader("Y\n"), new(bytes.Buffer), true)
+	got, err := p.Confirm(Recreate, "Delete and recreate")
+	assert.NilError(t, err)
+	assert.Equal(t, got, true)
+
+	p = NewWithIO(Options{}, strings.NewReader("\n"), new(bytes.Buffer), true)
+	got, err = p.Confirm(Recreate, "Delete and recreate")
+	assert.NilError(t, err)
+	assert.Equal(t, got, false)
+}
+
+func TestNonInteractiveFailsFast(t *testing.T) {
+	p := NewWithIO(Options{ReuseExisting: true}, strings.NewReader(""), new(bytes.Buffer), false)
+	_, err := p.Confirm(VersionWarning, "Continue anyway?")
+	assert.Assert(t, errors.Is(err, ErrNonInteractive))
+	assert.ErrorContains(t, err, "--ignore-version-warnings")
+
+	err = p.WaitForEnter("Press the Enter key to continue")
+	assert.Assert(t, errors.Is(err, ErrNonInteractive))
+
+	p = NewWithIO(Options{Yes: true}, strings.NewReader(""), new(bytes.Buffer), false)
+	assert.NilError(t, p.WaitForEnter("Press the Enter key to continue"))
+}
@@ -0,0 +1,49 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quickstart
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// DeleteKubeContext removes a leftover kubeconfig context, cluster and user entry.
+// The cluster tools normally clean these up themselves, so missing entries are not
+// an error.
+func DeleteKubeContext(ctx context.Context, kubeContext string) error {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return nil
+	}
+	getContexts := exec.CommandContext(ctx, "kubectl", "config", "get-contexts", "-o", "name")
+	out, err := getContexts.Output()
+	if err != nil {
+		return fmt.Errorf("unable to get kubeconfig contexts: %w", err)
+	}
+	if !slices.Contains(strings.Fields(string(out)), kubeContext) {
+		return nil
+	}
+
+	fmt.Println("    Removing kubeconfig context " + kubeContext + "...")
+	for _, entry := range []string{"delete-context", "delete-cluster", "delete-user"} {
+		if out, err := exec.CommandContext(ctx, "kubectl", "config", entry, kubeContext).CombinedOutput(); err != nil && entry == "delete-context" {
+			fmt.Println(string(out))
+			return fmt.Errorf("failed to delete kubeconfig context %s: %w", kubeContext, err)
+		}
+	}
+	return nil
+}
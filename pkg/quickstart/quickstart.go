@@ -0,0 +1,282 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quickstart
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"knative.dev/kn-plugin-quickstart/pkg/config"
+	qerrors "knative.dev/kn-plugin-quickstart/pkg/errors"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+	"knative.dev/kn-plugin-quickstart/pkg/prompt"
+)
+
+// ClusterProvider creates and manages the local cluster Knative is installed on
+type ClusterProvider interface {
+	// Name is the provider name shown to the user, e.g. "kind"
+	Name() string
+	// ClusterName is the name of the cluster managed by the provider
+	ClusterName() string
+	// Preflight checks that the tools needed by the provider are available
+	Preflight(ctx context.Context) error
+	// Exists reports whether the cluster already exists
+	Exists(ctx context.Context) (bool, error)
+	// Create creates the cluster
+	Create(ctx context.Context) error
+	// Delete deletes the cluster and everything quickstart created along with it
+	Delete(ctx context.Context) error
+	// KubeContext is the kubeconfig context of the cluster
+	KubeContext() string
+	// ConfigureRegistry sets up a local container registry for the cluster. It returns
+	// the registries Serving should skip tag resolution for, or "" if there are none.
+	ConfigureRegistry(ctx context.Context) (string, error)
+	// ConfigureIngress exposes the networking layer, install.Ingress, outside of the cluster
+	ConfigureIngress(ctx context.Context) error
+}
+
+// Options selects what is installed on the cluster
+type Options struct {
+	InstallServing  bool
+	InstallEventing bool
+	Prompter        prompt.Prompter
+	// ServingVersion, KourierVersion and EventingVersion override the build-time
+	// component versions when set
+	ServingVersion  string
+	KourierVersion  string
+	EventingVersion string
+	// ManifestSource is a base URL or local directory to read release manifests from,
+	// instead of github.com
+	ManifestSource string
+	// Ingress is the networking layer installed with Serving, Kourier by default
+	Ingress string
+	// BrokerClass is the broker class installed with Eventing, the multi-tenant
+	// channel based broker by default
+	BrokerClass string
+	// ConfigMaps is data merged into ConfigMaps once the components are installed
+	ConfigMaps []config.ConfigMapPatch
+	// DryRun records the cluster configuration and the changes to the cluster instead
+	// of making them when set. Prerequisites are not checked.
+	DryRun install.Recorder
+	// ExistingCluster installs onto the provider's cluster as it is, without creating,
+	// recreating or asking about it
+	ExistingCluster bool
+	// SkipSteps and OnlySteps select the steps that run by name, see StepNames. Steps
+	// that do not run are assumed to be done already.
+	SkipSteps []string
+	OnlySteps []string
+	// CleanupOnFailure deletes the cluster, and its registry, when the installation
+	// fails or is interrupted after creating it
+	CleanupOnFailure bool
+	// Resume continues the last installation on the provider's cluster, which failed,
+	// from the first step that did not complete or is not healthy anymore
+	Resume bool
+	// Timeout limits the whole installation when set. Waits and retries end with it,
+	// instead of after their own default timeouts.
+	Timeout time.Duration
+	// StepTimeouts limit single steps by name, within Timeout
+	StepTimeouts map[string]time.Duration
+	// UseKubectl applies, patches and waits for resources with kubectl instead of the
+	// Kubernetes API
+	UseKubectl bool
+}
+
+// Run creates the provider's cluster, or reuses an existing one, and installs all the
+// relevant Knative components. It runs the steps of Plan in order and reports the
+// duration of each.
+func Run(ctx context.Context, p ClusterProvider, opts Options) error {
+	opts, err := prepare(opts)
+	if err != nil {
+		return err
+	}
+
+	install.DryRun = opts.DryRun
+	install.UseKubectl = opts.UseKubectl
+	// Pin every kubectl invocation to the provider's cluster, so that a different or
+	// changing current context never gets patched
+	install.KubeContext = p.KubeContext()
+
+	r := &runner{p: p, opts: opts, installKnative: true}
+	steps := r.steps()
+	if err := selectSteps(steps, opts.SkipSteps, opts.OnlySteps); err != nil {
+		return err
+	}
+	if err := checkStepNames(slices.Collect(maps.Keys(opts.StepTimeouts))); err != nil {
+		return err
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	// Dry runs do not change the cluster, so there is no progress to record
+	var state *State
+	if opts.DryRun == nil {
+		if state, err = r.prepareState(ctx, steps); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	var durations []stepDuration
+	for _, s := range steps {
+		if s.Skip || (s.knative && !r.installKnative) {
+			continue
+		}
+		stepStart := time.Now()
+		if err := r.runStep(ctx, s); err != nil {
+			if opts.CleanupOnFailure && r.created {
+				r.cleanup(ctx)
+			} else if state != nil {
+				state.Failed = s.Name
+				if err := state.save(p); err != nil {
+					fmt.Println("    " + err.Error())
+				}
+			}
+			return err
+		}
+		durations = append(durations, stepDuration{s.Name, time.Since(stepStart)})
+		if state != nil {
+			state.Completed = append(state.Completed, s.Name)
+			state.Registries = r.registries
+			if err := state.save(p); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.DryRun != nil {
+		return nil
+	}
+	if err := removeState(p); err != nil {
+		return err
+	}
+
+	fmt.Println("⏱️  Step durations:")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, d := range durations {
+		fmt.Fprintf(tw, "    %s\t%s\t\n", d.name, d.duration.Round(time.Second))
+	}
+	tw.Flush()
+	fmt.Printf("🚀 Knative install took: %s \n", time.Since(start).Round(time.Second))
+	fmt.Println("🎉 Now have some fun with Serverless and Event Driven Apps!")
+	return nil
+}
+
+type stepDuration struct {
+	name     string
+	duration time.Duration
+}
+
+// prepare defaults the options and applies the version, source, networking layer and
+// broker class selection
+func prepare(opts Options) (Options, error) {
+	// if neither the "install-serving" or "install-eventing" flags are set,
+	// then we assume the user wants to install both serving and eventing
+	if !opts.InstallServing && !opts.InstallEventing {
+		opts.InstallServing = true
+		opts.InstallEventing = true
+	}
+
+	if err := install.OverrideVersions(opts.ServingVersion, opts.KourierVersion, opts.EventingVersion); err != nil {
+		return opts, err
+	}
+	if err := install.SetManifestSource(opts.ManifestSource); err != nil {
+		return opts, err
+	}
+	if err := install.SetIngress(opts.Ingress); err != nil {
+		return opts, err
+	}
+	if err := install.SetBrokerClass(opts.BrokerClass); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// ensureCluster checks if the user already has a quickstart cluster. If so, it provides
+// the option of deleting the existing cluster and recreating it. If not, it creates a
+// new cluster. It returns false if Knative is already installed and should be kept,
+// and whether it created the cluster, also when creating it failed.
+func ensureCluster(ctx context.Context, p ClusterProvider, prompter prompt.Prompter) (installKnative, created bool, err error) {
+	exists, err := p.Exists(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	if !exists {
+		return true, true, p.Create(ctx)
+	}
+
+	resp, err := prompter.Confirm(prompt.Recreate, "\nKnative Cluster "+p.KubeContext()+" already installed.\nDelete and recreate")
+	if err != nil {
+		return false, false, err
+	}
+	if resp {
+		return true, true, recreateCluster(ctx, p)
+	}
+
+	fmt.Println("\n    Installation skipped")
+	checkKnativeNamespace := install.Kubectl(ctx, "get", "namespaces")
+	output, err := checkKnativeNamespace.CombinedOutput()
+	if err != nil {
+		fmt.Println(string(output))
+		return false, false, fmt.Errorf("unable to get kubernetes namespaces for %s cluster %s: %w", p.Name(), p.ClusterName(), err)
+	}
+	if !strings.Contains(string(output), "knative") {
+		return true, false, nil
+	}
+
+	resp, err = prompter.Confirm(prompt.Recreate, "Knative installation already exists.\nDelete and recreate the cluster")
+	if err != nil {
+		return false, false, err
+	}
+	if !resp {
+		fmt.Println("Skipping installation")
+		return false, false, nil
+	}
+	return true, true, recreateCluster(ctx, p)
+}
+
+// recreateCluster deletes and creates the provider's cluster
+func recreateCluster(ctx context.Context, p ClusterProvider) error {
+	fmt.Println("\n    Deleting cluster...")
+	if err := p.Delete(ctx); err != nil {
+		return fmt.Errorf("failed while recreating %s cluster %s: %w", p.Name(), p.ClusterName(), err)
+	}
+	return p.Create(ctx)
+}
+
+// Delete deletes the provider's cluster, if it exists, and everything quickstart
+// created along with it
+func Delete(ctx context.Context, p ClusterProvider) error {
+	if err := p.Delete(ctx); err != nil {
+		return err
+	}
+	if err := removeState(p); err != nil {
+		return err
+	}
+	fmt.Println("🧹 Knative quickstart environment deleted")
+	return nil
+}
+
+func installError(p ClusterProvider, step, msg string, err error) error {
+	return &qerrors.InstallError{Step: step, Err: fmt.Errorf("%s %s cluster %s: %w", msg, p.Name(), p.ClusterName(), err)}
+}
@@ -0,0 +1,127 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quickstart
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	qerrors "knative.dev/kn-plugin-quickstart/pkg/errors"
+	"knative.dev/kn-plugin-quickstart/pkg/prompt"
+)
+
+type fakeProvider struct {
+	exists bool
+	calls  []string
+	// onCreate is called by Create, e.g. to interrupt the installation
+	onCreate func()
+}
+
+func (f *fakeProvider) Name() string                    { return "fake" }
+func (f *fakeProvider) ClusterName() string             { return "knative" }
+func (f *fakeProvider) KubeContext() string             { return "fake-knative" }
+func (f *fakeProvider) Preflight(context.Context) error { return nil }
+func (f *fakeProvider) Exists(context.Context) (bool, error) {
+	return f.exists, nil
+}
+func (f *fakeProvider) Create(ctx context.Context) error {
+	f.calls = append(f.calls, "create")
+	f.exists = true
+	if f.onCreate != nil {
+		f.onCreate()
+	}
+	return ctx.Err()
+}
+func (f *fakeProvider) Delete(context.Context) error {
+	f.calls = append(f.calls, "delete")
+	f.exists = false
+	return nil
+}
+func (f *fakeProvider) ConfigureRegistry(context.Context) (string, error) { return "", nil }
+func (f *fakeProvider) ConfigureIngress(context.Context) error            { return nil }
+
+func TestEnsureClusterCreatesMissingCluster(t *testing.T) {
+	p := &fakeProvider{}
+	prompter := prompt.NewWithIO(prompt.Options{}, strings.NewReader(""), new(bytes.Buffer), false)
+
+	install, created, err := ensureCluster(context.Background(), p, prompter)
+	assert.NilError(t, err)
+	assert.Equal(t, install, true)
+	assert.Equal(t, created, true)
+	assert.DeepEqual(t, p.calls, []string{"create"})
+}
+
+func TestEnsureClusterRecreatesExistingCluster(t *testing.T) {
+	p := &fakeProvider{exists: true}
+	prompter := prompt.NewWithIO(prompt.Options{Recreate: true}, strings.NewReader(""), new(bytes.Buffer), false)
+
+	install, created, err := ensureCluster(context.Background(), p, prompter)
+	assert.NilError(t, err)
+	assert.Equal(t, install, true)
+	assert.Equal(t, created, true)
+	assert.DeepEqual(t, p.calls, []string{"delete", "create"})
+}
+
+func TestEnsureClusterNonInteractive(t *testing.T) {
+	p := &fakeProvider{exists: true}
+	prompter := prompt.NewWithIO(prompt.Options{}, strings.NewReader(""), new(bytes.Buffer), false)
+
+	_, _, err := ensureCluster(context.Background(), p, prompter)
+	assert.ErrorIs(t, err, prompt.ErrNonInteractive)
+	assert.Equal(t, len(p.calls), 0)
+}
+
+func TestRunInterrupted(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &fakeProvider{onCreate: cancel}
+	err := Run(ctx, p, Options{SkipSteps: []string{"preflight"}})
+	assert.ErrorIs(t, err, qerrors.ErrInterrupted)
+	assert.ErrorContains(t, err, "installation interrupted during step cluster")
+	state, err := LoadState(p)
+	assert.NilError(t, err)
+	assert.Equal(t, state.Failed, "cluster")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	p = &fakeProvider{onCreate: cancel}
+	err = Run(ctx, p, Options{SkipSteps: []string{"preflight"}, CleanupOnFailure: true})
+	assert.ErrorIs(t, err, qerrors.ErrInterrupted)
+	assert.DeepEqual(t, p.calls, []string{"create", "delete"})
+	state, err = LoadState(p)
+	assert.NilError(t, err)
+	assert.Assert(t, state == nil)
+}
+
+func TestRunStepTimeout(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	p := &fakeProvider{onCreate: func() { time.Sleep(100 * time.Millisecond) }}
+	err := Run(context.Background(), p, Options{
+		SkipSteps:    []string{"preflight"},
+		StepTimeouts: map[string]time.Duration{"cluster": 10 * time.Millisecond},
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "step cluster timed out")
+	assert.Assert(t, !errors.Is(err, qerrors.ErrInterrupted))
+
+	err = Run(context.Background(), p, Options{StepTimeouts: map[string]time.Duration{"servingcore": time.Minute}})
+	assert.ErrorContains(t, err, `unknown step "servingcore"`)
+}
@@ -0,0 +1,153 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quickstart
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+)
+
+// State records the steps of an installation that completed, so that a failed
+// installation can be resumed. It is stored per cluster under the user cache directory.
+type State struct {
+	Provider string `json:"provider"`
+	Cluster  string `json:"cluster"`
+	// Ingress, BrokerClass and the versions are the options of the installation, which
+	// a resumed installation must use too
+	Ingress         string   `json:"ingress"`
+	BrokerClass     string   `json:"brokerClass"`
+	ServingVersion  string   `json:"servingVersion"`
+	KourierVersion  string   `json:"kourierVersion"`
+	EventingVersion string   `json:"eventingVersion"`
+	Completed       []string `json:"completed"`
+	// Failed is the step the installation failed at, if any
+	Failed string `json:"failed,omitempty"`
+	// Registries is the result of the registry step, which later steps depend on
+	Registries string `json:"registries,omitempty"`
+}
+
+// newState returns the state of an installation on the provider's cluster with the
+// current networking layer, broker class and versions
+func newState(p ClusterProvider) *State {
+	return &State{
+		Provider:        p.Name(),
+		Cluster:         p.ClusterName(),
+		Ingress:         install.Ingress.Name(),
+		BrokerClass:     install.Broker.Name(),
+		ServingVersion:  install.ServingVersion,
+		KourierVersion:  install.KourierVersion,
+		EventingVersion: install.EventingVersion,
+	}
+}
+
+// checkOptions returns an error listing the options of current that differ from the
+// ones of the installation s recorded
+func (s *State) checkOptions(current *State) error {
+	var diffs []string
+	for _, o := range []struct{ flag, last, current string }{
+		{"--ingress", s.Ingress, current.Ingress},
+		{"--broker-class", s.BrokerClass, current.BrokerClass},
+		{"--serving-version", s.ServingVersion, current.ServingVersion},
+		{"--kourier-version", s.KourierVersion, current.KourierVersion},
+		{"--eventing-version", s.EventingVersion, current.EventingVersion},
+	} {
+		if o.last != o.current {
+			diffs = append(diffs, fmt.Sprintf("%s is %q but was %q", o.flag, o.current, o.last))
+		}
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("cannot resume the installation on %s cluster %s with different options: %s; pass the options of the installation, or run without --resume to install again",
+		s.Provider, s.Cluster, strings.Join(diffs, ", "))
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// statePath returns the path of the state file of the provider's cluster
+func statePath(p ClusterProvider) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find the installation state directory: %w", err)
+	}
+	name := unsafeChars.ReplaceAllString(p.Name()+"-"+p.ClusterName(), "_")
+	return filepath.Join(dir, "kn-quickstart", "state", name+".json"), nil
+}
+
+// LoadState returns the state of the last installation on the provider's cluster, or
+// nil if there is none
+func LoadState(p ClusterProvider) (*State, error) {
+	path, err := statePath(p)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installation state: %w", err)
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid installation state %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// save writes the state of the provider's cluster
+func (s *State) save(p ClusterProvider) error {
+	path, err := statePath(p)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to write installation state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write installation state: %w", err)
+	}
+	return nil
+}
+
+// completed reports whether the named step completed
+func (s *State) completed(step string) bool {
+	return slices.Contains(s.Completed, step)
+}
+
+// removeState removes the state file of the provider's cluster, if any
+func removeState(p ClusterProvider) error {
+	path, err := statePath(p)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove installation state: %w", err)
+	}
+	return nil
+}
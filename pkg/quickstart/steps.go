@@ -0,0 +1,315 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quickstart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	qerrors "knative.dev/kn-plugin-quickstart/pkg/errors"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+)
+
+// StepNames are the names of all steps, in the order they run. Which of them are part
+// of an installation depends on the provider and options, see Plan.
+var StepNames = []string{
+	"preflight",
+	"cluster",
+	"registry",
+	"serving-crds",
+	"serving-core",
+	"serving-webhook",
+	"serving-registry",
+	"networking",
+	"ingress",
+	"eventing-crds",
+	"eventing-core",
+	"broker",
+	"example-broker",
+	"configmaps",
+}
+
+// Step is a named part of the installation
+type Step struct {
+	Name        string
+	Description string
+	// Skip is set for steps deselected by Options.SkipSteps or Options.OnlySteps
+	Skip bool
+
+	run func(context.Context) error
+	// verify checks that the step, completed by an earlier installation, is still
+	// healthy when resuming. Steps without verify are trusted.
+	verify func(context.Context) error
+	// knative steps do not run when the user chose to keep an existing installation
+	knative bool
+}
+
+// runner holds the state passed between the steps of a Run
+type runner struct {
+	p              ClusterProvider
+	opts           Options
+	installKnative bool
+	registries     string
+	// created is set once the cluster step created, or started creating, the cluster
+	created bool
+}
+
+// steps returns the steps of the installation, in order
+func (r *runner) steps() []Step {
+	p, opts := r.p, r.opts
+	steps := []Step{
+		{Name: "preflight", Description: "check that kubectl and the tools " + p.Name() + " needs are available", run: r.preflight, verify: r.preflight},
+	}
+	if !opts.ExistingCluster {
+		steps = append(steps, Step{Name: "cluster", Description: "create the " + p.Name() + " cluster, or reuse an existing one", run: r.cluster, verify: r.clusterExists})
+	}
+	steps = append(steps, Step{Name: "registry", Description: "set up the local container registry", run: r.registry})
+	if opts.InstallServing {
+		steps = append(steps,
+			Step{Name: "serving-crds", Description: "install the Knative Serving v" + install.ServingVersion + " CRDs", run: r.wrap("serving-crds", "failed to install serving CRDs to", install.ServingCRDs), verify: install.CheckCRDs, knative: true},
+			Step{Name: "serving-core", Description: "install Knative Serving core", run: r.wrap("serving-core", "failed to install serving to", install.ServingCore), verify: checkDeployments("knative-serving"), knative: true},
+			Step{Name: "serving-webhook", Description: "wait for the Serving webhook", run: r.wrap("serving-webhook", "failed waiting for the serving webhook of", install.WaitForServingWebhook), knative: true},
+			Step{Name: "serving-registry", Description: "skip tag resolution for the local registry", run: r.wrap("serving-registry", "failed to configure serving for", func(ctx context.Context) error { return install.ConfigureRegistries(ctx, r.registries) }), knative: true},
+			Step{Name: "networking", Description: "install " + install.Ingress.Name(), run: r.wrap("networking", "failed to install "+install.Ingress.Name()+" to", install.Ingress.Install), verify: checkDeployments(install.Ingress.Namespaces()...), knative: true},
+			Step{Name: "ingress", Description: "expose " + install.Ingress.Name() + " and configure the domain", run: r.wrap("ingress", "failed while configuring "+install.Ingress.Name()+" for", p.ConfigureIngress), knative: true},
+		)
+	}
+	if opts.InstallEventing {
+		steps = append(steps,
+			Step{Name: "eventing-crds", Description: "install the Knative Eventing v" + install.EventingVersion + " CRDs", run: r.wrap("eventing-crds", "failed to install eventing CRDs to", install.EventingCRDs), verify: install.CheckCRDs, knative: true},
+			Step{Name: "eventing-core", Description: "install Knative Eventing core", run: r.wrap("eventing-core", "failed to install eventing to", install.EventingCore), verify: checkDeployments("knative-eventing"), knative: true},
+			Step{Name: "broker", Description: "install the " + install.Broker.Name() + " broker", run: r.wrap("broker", "failed to install the broker to", install.InstallBroker), verify: checkDeployments("knative-eventing"), knative: true},
+			Step{Name: "example-broker", Description: "create the example broker", run: r.wrap("example-broker", "failed to create the example broker on", install.ExampleBroker), knative: true},
+		)
+	}
+	if len(opts.ConfigMaps) > 0 {
+		steps = append(steps, Step{Name: "configmaps", Description: fmt.Sprintf("patch %d ConfigMaps", len(opts.ConfigMaps)), run: r.configMaps, knative: true})
+	}
+	return steps
+}
+
+// runStep runs the step within its timeout, if any. Errors of steps that are
+// interrupted or time out say so.
+func (r *runner) runStep(ctx context.Context, s Step) error {
+	if timeout, ok := r.opts.StepTimeouts[s.Name]; ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := s.run(ctx)
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return &qerrors.InstallError{Step: s.Name, Err: fmt.Errorf("%w during step %s", qerrors.ErrInterrupted, s.Name)}
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return &qerrors.InstallError{Step: s.Name, Err: fmt.Errorf("step %s timed out: %w", s.Name, err)}
+	}
+	return err
+}
+
+// checkDeployments returns a verify func checking the Deployments in the given namespaces
+func checkDeployments(namespaces ...string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		return install.CheckDeployments(ctx, namespaces...)
+	}
+}
+
+func (r *runner) preflight(ctx context.Context) error {
+	if r.opts.DryRun != nil {
+		return nil
+	}
+	// kubectl is required, fail if not found
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return &qerrors.PrerequisiteError{
+			Tool: "kubectl",
+			Hint: "Download from https://kubectl.docs.kubernetes.io/installation/kubectl/",
+			Err:  err,
+		}
+	}
+	return r.p.Preflight(ctx)
+}
+
+func (r *runner) cluster(ctx context.Context) error {
+	var err error
+	if r.opts.DryRun != nil {
+		err = r.p.Create(ctx)
+	} else {
+		r.installKnative, r.created, err = ensureCluster(ctx, r.p, r.opts.Prompter)
+	}
+	if err != nil {
+		return &qerrors.InstallError{Step: "cluster", Err: fmt.Errorf("failed to create %s cluster: %w", r.p.Name(), err)}
+	}
+	return nil
+}
+
+func (r *runner) clusterExists(ctx context.Context) error {
+	exists, err := r.p.Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%s cluster %s does not exist", r.p.Name(), r.p.ClusterName())
+	}
+	return nil
+}
+
+// cleanup deletes the cluster, and its registry, after a failed installation. It runs to
+// completion when ctx is cancelled.
+func (r *runner) cleanup(ctx context.Context) {
+	fmt.Println("🧹 Cleaning up " + r.p.Name() + " cluster " + r.p.ClusterName() + " after the failed installation...")
+	if err := Delete(context.WithoutCancel(ctx), r.p); err != nil {
+		fmt.Println("    Cleanup failed: " + err.Error())
+	}
+}
+
+func (r *runner) registry(ctx context.Context) error {
+	var err error
+	r.registries, err = r.p.ConfigureRegistry(ctx)
+	if err != nil {
+		return &qerrors.InstallError{Step: "registry", Err: fmt.Errorf("failed to set up local registry for %s cluster %s: %w", r.p.Name(), r.p.ClusterName(), err)}
+	}
+	return nil
+}
+
+func (r *runner) configMaps(ctx context.Context) error {
+	for _, cm := range r.opts.ConfigMaps {
+		if err := install.PatchConfigMap(ctx, cm.Namespace, cm.Name, cm.Data); err != nil {
+			return installError(r.p, "configmaps", "failed to patch ConfigMap "+cm.Namespace+"/"+cm.Name+" of", err)
+		}
+		fmt.Println("    Patched ConfigMap " + cm.Namespace + "/" + cm.Name + "...")
+	}
+	return nil
+}
+
+// wrap returns a step running fn, which returns an InstallError for the step on failure
+func (r *runner) wrap(step, msg string, fn func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return installError(r.p, step, msg, err)
+		}
+		return nil
+	}
+}
+
+// prepareState returns the state the installation records its progress in. With
+// Options.Resume, the steps an earlier installation completed are marked as skipped,
+// up to the first one that is not healthy anymore. Resuming an installation with a
+// different networking layer, broker class or versions is an error.
+func (r *runner) prepareState(ctx context.Context, steps []Step) (*State, error) {
+	last, err := LoadState(r.p)
+	if err != nil {
+		return nil, err
+	}
+	state := newState(r.p)
+	if !r.opts.Resume {
+		if last != nil && last.Failed != "" {
+			fmt.Println("    The last installation on this cluster failed at step " + last.Failed + ", pass --resume to continue it")
+		}
+		return state, nil
+	}
+	if last == nil {
+		return nil, fmt.Errorf("no installation to resume on %s cluster %s", r.p.Name(), r.p.ClusterName())
+	}
+	if err := last.checkOptions(state); err != nil {
+		return nil, err
+	}
+
+	fmt.Println("🔁 Resuming the installation on " + r.p.Name() + " cluster " + r.p.ClusterName() + "...")
+	r.registries = last.Registries
+	state.Registries = last.Registries
+	for i := range steps {
+		s := &steps[i]
+		if s.Skip {
+			continue
+		}
+		if !last.completed(s.Name) {
+			break
+		}
+		if s.verify != nil {
+			if err := s.verify(ctx); err != nil {
+				fmt.Printf("    Step %s is not healthy anymore, running it again: %v\n", s.Name, err)
+				break
+			}
+		}
+		fmt.Println("    Step " + s.Name + " already completed...")
+		s.Skip = true
+		state.Completed = append(state.Completed, s.Name)
+	}
+	return state, nil
+}
+
+// selectSteps marks the steps not selected by skip and only as skipped. Unknown step
+// names are an error.
+func selectSteps(steps []Step, skip, only []string) error {
+	if err := checkStepNames(slices.Concat(skip, only)); err != nil {
+		return err
+	}
+	for i := range steps {
+		steps[i].Skip = slices.Contains(skip, steps[i].Name) || (len(only) > 0 && !slices.Contains(only, steps[i].Name))
+	}
+	return nil
+}
+
+// checkStepNames returns an error for the first name that is not in StepNames
+func checkStepNames(names []string) error {
+	for _, name := range names {
+		if !slices.Contains(StepNames, name) {
+			return fmt.Errorf("unknown step %q, must be one of: %s", name, strings.Join(StepNames, ", "))
+		}
+	}
+	return nil
+}
+
+// Plan returns the steps Run runs for the provider and options, in order. Steps
+// deselected by the options are included, marked as skipped.
+func Plan(p ClusterProvider, opts Options) ([]Step, error) {
+	opts, err := prepare(opts)
+	if err != nil {
+		return nil, err
+	}
+	steps := (&runner{p: p, opts: opts, installKnative: true}).steps()
+	if err := selectSteps(steps, opts.SkipSteps, opts.OnlySteps); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// PrintPlan writes the steps Run runs for the provider and options to w
+func PrintPlan(w io.Writer, p ClusterProvider, opts Options) error {
+	steps, err := Plan(p, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "📋 Installation plan for "+p.Name()+":")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	n := 0
+	for _, s := range steps {
+		if s.Skip {
+			fmt.Fprintf(tw, "    -\t%s\t%s (skipped)\n", s.Name, s.Description)
+			continue
+		}
+		n++
+		fmt.Fprintf(tw, "    %d.\t%s\t%s\n", n, s.Name, s.Description)
+	}
+	return tw.Flush()
+}
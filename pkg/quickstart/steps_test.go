@@ -0,0 +1,114 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quickstart
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"knative.dev/kn-plugin-quickstart/pkg/config"
+	"knative.dev/kn-plugin-quickstart/pkg/install"
+)
+
+func stepNames(steps []Step) []string {
+	var names []string
+	for _, s := range steps {
+		if !s.Skip {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+func TestPlan(t *testing.T) {
+	steps, err := Plan(&fakeProvider{}, Options{ConfigMaps: []config.ConfigMapPatch{{Namespace: "knative-serving", Name: "config-network"}}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, stepNames(steps), StepNames)
+
+	steps, err = Plan(&fakeProvider{}, Options{InstallEventing: true, ExistingCluster: true})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, stepNames(steps), []string{"preflight", "registry", "eventing-crds", "eventing-core", "broker", "example-broker"})
+}
+
+func TestPlanSelectsSteps(t *testing.T) {
+	steps, err := Plan(&fakeProvider{}, Options{InstallServing: true, SkipSteps: []string{"cluster", "serving-registry"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, stepNames(steps), []string{"preflight", "registry", "serving-crds", "serving-core", "serving-webhook", "networking", "ingress"})
+
+	steps, err = Plan(&fakeProvider{}, Options{OnlySteps: []string{"broker", "example-broker"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, stepNames(steps), []string{"broker", "example-broker"})
+
+	_, err = Plan(&fakeProvider{}, Options{SkipSteps: []string{"kourier"}})
+	assert.ErrorContains(t, err, `unknown step "kourier"`)
+}
+
+func TestResume(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	p := &fakeProvider{}
+	r := &runner{p: p, opts: Options{Resume: true}}
+
+	_, err := r.prepareState(context.Background(), nil)
+	assert.ErrorContains(t, err, "no installation to resume on fake cluster knative")
+
+	last := newState(p)
+	last.Completed = []string{"cluster", "registry", "serving-crds"}
+	last.Failed = "serving-core"
+	last.Registries = "localhost:5001"
+	assert.NilError(t, last.save(p))
+	loaded, err := LoadState(p)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, loaded, last)
+
+	healthy := true
+	newSteps := func() []Step {
+		return []Step{
+			{Name: "cluster"},
+			{Name: "registry"},
+			{Name: "serving-crds", verify: func(context.Context) error {
+				if !healthy {
+					return errors.New("crds not established")
+				}
+				return nil
+			}},
+			{Name: "serving-core"},
+		}
+	}
+	steps := newSteps()
+	state, err := r.prepareState(context.Background(), steps)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, stepNames(steps), []string{"serving-core"})
+	assert.DeepEqual(t, state.Completed, []string{"cluster", "registry", "serving-crds"})
+	assert.Equal(t, r.registries, "localhost:5001")
+
+	healthy = false
+	steps = newSteps()
+	_, err = r.prepareState(context.Background(), steps)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, stepNames(steps), []string{"serving-crds", "serving-core"})
+
+	// Resuming with another broker class would skip the steps of the installed one
+	assert.NilError(t, install.SetBrokerClass("kafka"))
+	t.Cleanup(func() { assert.Check(t, install.SetBrokerClass("mt-channel")) })
+	_, err = r.prepareState(context.Background(), newSteps())
+	assert.ErrorContains(t, err, `cannot resume the installation on fake cluster knative with different options: --broker-class is "kafka" but was "mt-channel"`)
+
+	assert.NilError(t, removeState(p))
+	loaded, err = LoadState(p)
+	assert.NilError(t, err)
+	assert.Assert(t, loaded == nil)
+}
@@ -0,0 +1,506 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+	"knative.dev/kn-plugin-quickstart/pkg/k3d"
+	"knative.dev/kn-plugin-quickstart/pkg/kind"
+	"knative.dev/kn-plugin-quickstart/pkg/minikube"
+)
+
+// Cluster is the health report for a single quickstart cluster
+type Cluster struct {
+	Name       string      `json:"name"`
+	Provider   string      `json:"provider"`
+	Context    string      `json:"context"`
+	Reachable  bool        `json:"reachable"`
+	Message    string      `json:"message,omitempty"`
+	Components []Component `json:"components,omitempty"`
+	Registry   *Registry   `json:"registry,omitempty"`
+	Ingress    *Ingress    `json:"ingress,omitempty"`
+}
+
+// Component is the install and readiness state of a Knative component
+type Component struct {
+	Name      string `json:"name"`
+	Installed bool   `json:"installed"`
+	Version   string `json:"version,omitempty"`
+	Ready     bool   `json:"ready"`
+	Message   string `json:"message,omitempty"`
+}
+
+// Registry is the state of the local registry container
+type Registry struct {
+	Name    string `json:"name"`
+	Running bool   `json:"running"`
+}
+
+// Ingress is the result of probing the cluster ingress
+type Ingress struct {
+	URL       string `json:"url,omitempty"`
+	Answering bool   `json:"answering"`
+	Message   string `json:"message,omitempty"`
+}
+
+// componentCheck describes the deployments which make up a component
+type componentCheck struct {
+	name        string
+	namespace   string
+	deployments []string
+}
+
+var (
+	servingCheck  = componentCheck{"Serving", "knative-serving", []string{"activator", "autoscaler", "controller", "webhook"}}
+	eventingCheck = componentCheck{"Eventing", "knative-eventing", []string{"eventing-controller", "eventing-webhook"}}
+)
+
+// Ingress and broker classes quickstart installs by default, which Serving and
+// Eventing use when their ConfigMaps do not select a class
+const (
+	defaultIngressClass = "kourier.ingress.networking.knative.dev"
+	defaultBrokerClass  = "MTChannelBasedBroker"
+)
+
+// ingressChecks are the components of the networking layers, by the ingress class
+// Serving is configured with
+var ingressChecks = map[string][]componentCheck{
+	"kourier.ingress.networking.knative.dev": {
+		{"Kourier", "kourier-system", []string{"3scale-kourier-gateway"}},
+		{"net-kourier", "knative-serving", []string{"net-kourier-controller"}},
+	},
+	"contour.ingress.networking.knative.dev": {
+		{"Contour", "contour-external", []string{"contour"}},
+		{"net-contour", "knative-serving", []string{"net-contour-controller"}},
+	},
+	"istio.ingress.networking.knative.dev": {
+		{"Istio", "istio-system", []string{"istiod", "istio-ingressgateway"}},
+		{"net-istio", "knative-serving", []string{"net-istio-controller", "net-istio-webhook"}},
+	},
+	"gateway-api.ingress.networking.knative.dev": {
+		{"Envoy Gateway", "envoy-gateway-system", []string{"envoy-gateway"}},
+		{"net-gateway-api", "knative-serving", []string{"net-gateway-api-controller", "net-gateway-api-webhook"}},
+	},
+}
+
+// brokerChecks are the components of the broker implementations, by the default
+// broker class of Eventing
+var brokerChecks = map[string][]componentCheck{
+	"MTChannelBasedBroker": {
+		{"In-memory channel", "knative-eventing", []string{"imc-controller", "imc-dispatcher"}},
+		{"MT channel broker", "knative-eventing", []string{"mt-broker-controller", "mt-broker-filter", "mt-broker-ingress"}},
+	},
+	"Kafka": {
+		{"Kafka", "kafka", []string{"kafka"}},
+		{"Kafka broker", "knative-eventing", []string{"kafka-controller", "kafka-webhook-eventing", "kafka-broker-receiver"}},
+	},
+	"RabbitMQBroker": {
+		{"RabbitMQ operators", "rabbitmq-system", []string{"rabbitmq-cluster-operator", "messaging-topology-operator"}},
+		{"RabbitMQ broker", "knative-eventing", []string{"rabbitmq-broker-controller", "rabbitmq-broker-webhook"}},
+	},
+}
+
+// Detect finds the existing Kind and k3d clusters and Minikube profiles and reports
+// their health. If name is set, only clusters with that name are reported. The checks
+// are canceled once ctx is done.
+func Detect(ctx context.Context, name string) ([]Cluster, error) {
+	var clusters []Cluster
+
+	if _, err := exec.LookPath("kind"); err == nil {
+		names, err := kind.Clusters(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range names {
+			if name != "" && n != name {
+				continue
+			}
+			clusters = append(clusters, kindCluster(ctx, n))
+		}
+	}
+
+	if _, err := exec.LookPath("k3d"); err == nil {
+		names, err := k3d.Clusters(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range names {
+			if name != "" && n != name {
+				continue
+			}
+			clusters = append(clusters, k3dCluster(ctx, n))
+		}
+	}
+
+	if _, err := exec.LookPath("minikube"); err == nil {
+		names, err := minikube.Profiles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range names {
+			if name != "" && n != name {
+				continue
+			}
+			clusters = append(clusters, minikubeCluster(ctx, n))
+		}
+	}
+
+	return clusters, nil
+}
+
+func kindCluster(ctx context.Context, name string) Cluster {
+	c := Cluster{Name: name, Provider: "kind", Context: "kind-" + name}
+	checkComponents(ctx, &c)
+
+	running, err := kind.RegistryRunning(ctx)
+	c.Registry = &Registry{Name: "kind-registry", Running: running && err == nil}
+
+	c.Ingress = &Ingress{}
+	if port, err := kind.IngressHostPort(ctx, name); err != nil {
+		c.Ingress.Message = err.Error()
+	} else {
+		probeIngress(ctx, c.Ingress, fmt.Sprintf("http://127.0.0.1:%d", port))
+	}
+	return c
+}
+
+func k3dCluster(ctx context.Context, name string) Cluster {
+	c := Cluster{Name: name, Provider: "k3d", Context: "k3d-" + name}
+	checkComponents(ctx, &c)
+
+	// The registry is only created with --registry
+	if running, found, err := k3d.RegistryRunning(ctx, name); err == nil && found {
+		c.Registry = &Registry{Name: k3d.RegistryName(name), Running: running}
+	}
+
+	c.Ingress = &Ingress{}
+	if port, err := k3d.IngressHostPort(ctx, name); err != nil {
+		c.Ingress.Message = err.Error()
+	} else {
+		probeIngress(ctx, c.Ingress, fmt.Sprintf("http://127.0.0.1:%d", port))
+	}
+	return c
+}
+
+func minikubeCluster(ctx context.Context, name string) Cluster {
+	c := Cluster{Name: name, Provider: "minikube", Context: name}
+	checkComponents(ctx, &c)
+
+	c.Ingress = &Ingress{}
+	if !c.Reachable {
+		c.Ingress.Message = "cluster not reachable"
+		return c
+	}
+	out, err := kubectl(ctx, c.Context, "get", "service", "kourier", "-n", "kourier-system",
+		"-o", "jsonpath={.status.loadBalancer.ingress[0].ip}")
+	ip := strings.TrimSpace(string(out))
+	switch {
+	case err != nil:
+		c.Ingress.Message = "kourier service not found"
+	case ip == "":
+		c.Ingress.Message = "kourier service has no external IP, is `minikube tunnel` running?"
+	default:
+		probeIngress(ctx, c.Ingress, "http://"+ip)
+	}
+	return c
+}
+
+// checkComponents fills in the component states of the cluster, marking the cluster
+// unreachable if its API server cannot be queried
+func checkComponents(ctx context.Context, c *Cluster) {
+	if out, err := kubectl(ctx, c.Context, "get", "namespaces", "-o", "name"); err != nil {
+		c.Message = strings.TrimSpace(string(out))
+		return
+	}
+	c.Reachable = true
+
+	ingressClass := configuredIngressClass(configMapData(ctx, c.Context, "knative-serving", "config-network"))
+	brokerClass := configuredBrokerClass(configMapData(ctx, c.Context, "knative-eventing", "config-br-defaults"))
+	checks := componentChecks(ingressClass, brokerClass)
+
+	deployments := map[string][]deployment{}
+	for _, check := range checks {
+		if _, ok := deployments[check.namespace]; ok {
+			continue
+		}
+		out, err := kubectl(ctx, c.Context, "get", "deployments", "-n", check.namespace, "-o", "json")
+		if err != nil {
+			deployments[check.namespace] = nil
+			continue
+		}
+		parsed, err := parseDeployments(out)
+		if err != nil {
+			deployments[check.namespace] = nil
+			continue
+		}
+		deployments[check.namespace] = parsed
+	}
+
+	for _, check := range checks {
+		c.Components = append(c.Components, evaluate(check, deployments[check.namespace]))
+	}
+	if _, ok := ingressChecks[ingressClass]; !ok {
+		c.Components = append(c.Components, Component{Name: "Ingress", Installed: true, Message: "unknown ingress class " + ingressClass})
+	}
+	if _, ok := brokerChecks[brokerClass]; !ok {
+		c.Components = append(c.Components, Component{Name: "Broker", Installed: true, Message: "unknown broker class " + brokerClass})
+	}
+	c.Components = append(c.Components, exampleBroker(ctx, c.Context))
+}
+
+// componentChecks returns the checks of Serving, Eventing and the components of the
+// given ingress and broker classes
+func componentChecks(ingressClass, brokerClass string) []componentCheck {
+	checks := []componentCheck{servingCheck}
+	checks = append(checks, ingressChecks[ingressClass]...)
+	checks = append(checks, eventingCheck)
+	return append(checks, brokerChecks[brokerClass]...)
+}
+
+// configuredIngressClass returns the ingress class of the config-network ConfigMap
+// data, or the default if it selects none
+func configuredIngressClass(data map[string]string) string {
+	for _, key := range []string{"ingress-class", "ingress.class"} {
+		if class := data[key]; class != "" {
+			return class
+		}
+	}
+	return defaultIngressClass
+}
+
+// configuredBrokerClass returns the cluster default broker class of the
+// config-br-defaults ConfigMap data, or the default if it selects none
+func configuredBrokerClass(data map[string]string) string {
+	var config struct {
+		ClusterDefault struct {
+			BrokerClass string `yaml:"brokerClass"`
+		} `yaml:"clusterDefault"`
+	}
+	if err := yaml.Unmarshal([]byte(data["default-br-config"]), &config); err != nil || config.ClusterDefault.BrokerClass == "" {
+		return defaultBrokerClass
+	}
+	return config.ClusterDefault.BrokerClass
+}
+
+// configMapData returns the data of the ConfigMap, or nil if it cannot be read
+func configMapData(ctx context.Context, kubeContext, ns, name string) map[string]string {
+	out, err := kubectl(ctx, kubeContext, "get", "configmap", name, "-n", ns, "-o", "json")
+	if err != nil {
+		return nil
+	}
+	var cm struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(out, &cm); err != nil {
+		return nil
+	}
+	return cm.Data
+}
+
+type deployment struct {
+	Metadata struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Status struct {
+		Conditions []condition `json:"conditions"`
+	} `json:"status"`
+}
+
+type condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+func parseDeployments(out []byte) ([]deployment, error) {
+	var list struct {
+		Items []deployment `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("unable to parse deployments: %w", err)
+	}
+	return list.Items, nil
+}
+
+// evaluate computes the state of a component from the deployments found in its namespace
+func evaluate(check componentCheck, deployments []deployment) Component {
+	comp := Component{Name: check.name}
+	found := map[string]deployment{}
+	for _, d := range deployments {
+		found[d.Metadata.Name] = d
+	}
+
+	var missing, notReady []string
+	for _, name := range check.deployments {
+		d, ok := found[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		if comp.Version == "" {
+			if v := d.Metadata.Labels["app.kubernetes.io/version"]; v != "" {
+				comp.Version = "v" + strings.TrimPrefix(v, "v")
+			}
+		}
+		if !conditionTrue(d.Status.Conditions, "Available") {
+			notReady = append(notReady, name)
+		}
+	}
+
+	switch {
+	case len(missing) == len(check.deployments):
+		comp.Message = "not installed"
+	case len(missing) > 0:
+		comp.Installed = true
+		comp.Message = "missing " + strings.Join(missing, ", ")
+	case len(notReady) > 0:
+		comp.Installed = true
+		comp.Message = "not ready: " + strings.Join(notReady, ", ")
+	default:
+		comp.Installed = true
+		comp.Ready = true
+	}
+	return comp
+}
+
+func exampleBroker(ctx context.Context, kubeContext string) Component {
+	comp := Component{Name: "Example broker"}
+	out, err := kubectl(ctx, kubeContext, "get", "brokers.eventing.knative.dev", "example-broker", "-n", "default", "-o", "json")
+	if err != nil {
+		comp.Message = "not installed"
+		return comp
+	}
+	comp.Installed = true
+
+	var broker struct {
+		Status struct {
+			Conditions []condition `json:"conditions"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(out, &broker); err != nil {
+		comp.Message = "unable to parse broker status"
+		return comp
+	}
+	comp.Ready = conditionTrue(broker.Status.Conditions, "Ready")
+	if !comp.Ready {
+		comp.Message = "not ready"
+	}
+	return comp
+}
+
+func conditionTrue(conditions []condition, conditionType string) bool {
+	for _, c := range conditions {
+		if c.Type == conditionType {
+			return c.Status == "True"
+		}
+	}
+	return false
+}
+
+// probeIngress sends a request to the ingress and treats any HTTP response as answering
+func probeIngress(ctx context.Context, ingress *Ingress, url string) {
+	ingress.URL = url
+	client := http.Client{Timeout: 5 * time.Second}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		ingress.Message = err.Error()
+		return
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		ingress.Message = err.Error()
+		return
+	}
+	resp.Body.Close()
+	ingress.Answering = true
+}
+
+func kubectl(ctx context.Context, kubeContext string, args ...string) ([]byte, error) {
+	args = append([]string{"--context", kubeContext, "--request-timeout=5s"}, args...)
+	return exec.CommandContext(ctx, "kubectl", args...).CombinedOutput()
+}
+
+// Print writes a human readable report of the given clusters
+func Print(out io.Writer, clusters []Cluster) {
+	if len(clusters) == 0 {
+		fmt.Fprintln(out, "No quickstart clusters found")
+		return
+	}
+	for i, c := range clusters {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%s cluster %q (context %s)\n", c.Provider, c.Name, c.Context)
+		if !c.Reachable {
+			fmt.Fprintf(out, "    ❌ Cluster not reachable: %s\n", c.Message)
+			continue
+		}
+		for _, comp := range c.Components {
+			fmt.Fprintf(out, "    %s %-18s %-10s %s\n", mark(comp.Ready, comp.Installed), comp.Name, comp.Version, describe(comp))
+		}
+		if c.Registry != nil {
+			state := "not running"
+			if c.Registry.Running {
+				state = "running"
+			}
+			fmt.Fprintf(out, "    %s %-18s %-10s %s\n", mark(c.Registry.Running, false), "Registry", "", c.Registry.Name+" "+state)
+		}
+		if c.Ingress != nil {
+			state := c.Ingress.Message
+			if c.Ingress.Answering {
+				state = c.Ingress.URL + " answering"
+			}
+			fmt.Fprintf(out, "    %s %-18s %-10s %s\n", mark(c.Ingress.Answering, false), "Ingress", "", state)
+		}
+	}
+}
+
+// PrintJSON writes the given clusters as JSON
+func PrintJSON(out io.Writer, clusters []Cluster) error {
+	if clusters == nil {
+		clusters = []Cluster{}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(clusters)
+}
+
+func mark(ready, installed bool) string {
+	switch {
+	case ready:
+		return "✅"
+	case installed:
+		return "⚠️"
+	default:
+		return "❌"
+	}
+}
+
+func describe(comp Component) string {
+	if comp.Ready {
+		return "Ready"
+	}
+	return comp.Message
+}
@@ -0,0 +1,101 @@
+// Copyright © 2021 The Knative Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+const deploymentList = `{
+  "items": [
+    {
+      "metadata": {"name": "mt-broker-controller", "labels": {"app.kubernetes.io/version": "1.20.0"}},
+      "status": {"conditions": [{"type": "Available", "status": "True"}]}
+    },
+    {
+      "metadata": {"name": "mt-broker-filter", "labels": {"app.kubernetes.io/version": "1.20.0"}},
+      "status": {"conditions": [{"type": "Available", "status": "False"}]}
+    },
+    {
+      "metadata": {"name": "mt-broker-ingress"},
+      "status": {"conditions": [{"type": "Available", "status": "True"}]}
+    },
+    {
+      "metadata": {"name": "imc-controller", "labels": {"app.kubernetes.io/version": "1.20.0"}},
+      "status": {"conditions": [{"type": "Available", "status": "True"}]}
+    }
+  ]
+}`
+
+func TestEvaluate(t *testing.T) {
+	deployments, err := parseDeployments([]byte(deploymentList))
+	assert.NilError(t, err)
+
+	checks := componentChecks(defaultIngressClass, defaultBrokerClass)
+	broker := evaluate(checks[5], deployments)
+	assert.Equal(t, broker.Installed, true)
+	assert.Equal(t, broker.Ready, false)
+	assert.Equal(t, broker.Version, "v1.20.0")
+	assert.Equal(t, broker.Message, "not ready: mt-broker-filter")
+
+	imc := evaluate(checks[4], deployments)
+	assert.Equal(t, imc.Installed, true)
+	assert.Equal(t, imc.Message, "missing imc-dispatcher")
+
+	serving := evaluate(checks[0], deployments)
+	assert.Equal(t, serving.Installed, false)
+	assert.Equal(t, serving.Message, "not installed")
+}
+
+func TestComponentChecks(t *testing.T) {
+	names := func(checks []componentCheck) []string {
+		var names []string
+		for _, c := range checks {
+			names = append(names, c.name)
+		}
+		return names
+	}
+	assert.DeepEqual(t, names(componentChecks(defaultIngressClass, defaultBrokerClass)),
+		[]string{"Serving", "Kourier", "net-kourier", "Eventing", "In-memory channel", "MT channel broker"})
+	assert.DeepEqual(t, names(componentChecks("istio.ingress.networking.knative.dev", "Kafka")),
+		[]string{"Serving", "Istio", "net-istio", "Eventing", "Kafka", "Kafka broker"})
+	assert.DeepEqual(t, names(componentChecks("other", "RabbitMQBroker")),
+		[]string{"Serving", "Eventing", "RabbitMQ operators", "RabbitMQ broker"})
+}
+
+func TestConfiguredClasses(t *testing.T) {
+	assert.Equal(t, configuredIngressClass(nil), defaultIngressClass)
+	assert.Equal(t, configuredIngressClass(map[string]string{"ingress.class": "contour.ingress.networking.knative.dev"}), "contour.ingress.networking.knative.dev")
+	assert.Equal(t, configuredIngressClass(map[string]string{"ingress-class": "gateway-api.ingress.networking.knative.dev"}), "gateway-api.ingress.networking.knative.dev")
+
+	assert.Equal(t, configuredBrokerClass(nil), defaultBrokerClass)
+	assert.Equal(t, configuredBrokerClass(map[string]string{"default-br-config": `clusterDefault:
+  brokerClass: Kafka
+  apiVersion: v1
+  kind: ConfigMap
+  name: kafka-broker-config
+  namespace: knative-eventing
+`}), "Kafka")
+	assert.Equal(t, configuredBrokerClass(map[string]string{"default-br-config": "{"}), defaultBrokerClass)
+}
+
+func TestPrintJSONEmpty(t *testing.T) {
+	out := new(bytes.Buffer)
+	assert.NilError(t, PrintJSON(out, nil))
+	assert.Equal(t, out.String(), "[]\n")
+}